@@ -1,8 +1,12 @@
 package flowtest
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
 	"slices"
 
@@ -28,16 +32,31 @@ type Assertion interface {
 	// MustMessage is used to assert topic requests work
 	MustMessage(*emptypb.Empty, error)
 
-	// Equal asserts be want == got. If extraLog is set, and the first
-	// argument is a string it is used as a format string for the rest of the
-	// arguments. If the first argument is not a string, everything is just
-	// logged
-	Equal(want, got any)
+	// Equal asserts be want == got, failing with a field level diff. Protos
+	// are compared with protocmp, other values with cmp, both can be adjusted
+	// with options like IgnoreFields and IgnoreOrder.
+	Equal(want, got any, opts ...EqualOption)
+
+	// Proto returns assertions on the fields of msg, addressed by proto field
+	// paths like "order.items[0].sku".
+	Proto(msg proto.Message) ProtoAssertion
 
 	// CodeError asserts be the error returned was non-nil and a Status error
 	// with the given code
 	CodeError(err error, code codes.Code)
 
+	// StatusError asserts be the error is a Status error with the given code,
+	// matching every matcher, e.g. MessageContains or HasFieldViolation. The
+	// full status is printed on mismatch.
+	StatusError(err error, code codes.Code, matchers ...StatusMatcher)
+
+	// Valid asserts be msg passes its protovalidate rules
+	Valid(msg proto.Message)
+
+	// Invalid asserts be msg fails its protovalidate rules, with a violation
+	// of each of the given field paths, e.g. "order.items[0].sku"
+	Invalid(msg proto.Message, fieldPaths ...string)
+
 	// NotEmpty asserts be the given values are not nil or zero values (zero
 	// as in reflect.Value.IsZero)
 	NotEmpty(got ...any)
@@ -62,6 +81,12 @@ type Assertion interface {
 	// Errorf fails the test with the given format string
 	Errorf(format string, args ...any)
 
+	// Eventually runs fn repeatedly, every interval, until all of the
+	// assertions made within it pass. Failures are ignored until the timeout
+	// expires or the context is canceled, at which point the last failure is
+	// reported with the number of attempts.
+	Eventually(ctx context.Context, timeout, interval time.Duration, fn func(Assertion))
+
 	Helper()
 }
 
@@ -77,14 +102,24 @@ type assertion struct {
 	error  func(args ...any)
 	helper func()
 
+	// record, when set, receives the errors which cause assertions to fail.
+	record func(error)
+
 	assertionParent
 }
 
+// T fails with the outcome, passed as the only argument to fatal so that
+// loggers which understand it can render its fields. The name of a Sub
+// assertion is prefixed to the path of the outcome.
 func (a *assertion) T(outcome *be.Outcome) {
 	a.helper()
-	if outcome != nil {
-		a.fail(string(*outcome))
+	if outcome == nil {
+		return
+	}
+	if a.name != "" {
+		outcome = outcome.At(a.name)
 	}
+	a.fatal(outcome)
 }
 
 func (a *assertion) Sub(name string, args ...any) Assertion {
@@ -93,6 +128,7 @@ func (a *assertion) Sub(name string, args ...any) Assertion {
 		helper:          a.helper,
 		fatal:           a.fatal,
 		error:           a.error,
+		record:          a.record,
 		assertionParent: a,
 	}
 }
@@ -131,9 +167,16 @@ func (a *assertion) Errorf(format string, args ...any) {
 	a.error(fmt.Sprintf(format, args...))
 }
 
+func (a *assertion) recordError(err error) {
+	if a.record != nil {
+		a.record(err)
+	}
+}
+
 func (a *assertion) NoError(err error) {
 	a.helper()
 	if err != nil {
+		a.recordError(err)
 		statErr, ok := status.FromError(err)
 		if ok {
 			a.fail("unexpected error %s\n  %s\n", err.Error(), prototext.Format(statErr.Proto()))
@@ -149,7 +192,7 @@ func (a *assertion) MustMessage(m *emptypb.Empty, err error) {
 	a.NotNil(m)
 }
 
-func (a *assertion) Equal(want, got any) {
+func (a *assertion) Equal(want, got any, opts ...EqualOption) {
 	a.helper()
 	if got == nil || want == nil {
 		if got != want {
@@ -158,22 +201,16 @@ func (a *assertion) Equal(want, got any) {
 		return
 	}
 
-	if wantProto, ok := want.(proto.Message); ok {
-		gotProto, ok := got.(proto.Message)
-		if !ok {
+	if _, ok := want.(proto.Message); ok {
+		if _, ok := got.(proto.Message); !ok {
 			a.fail("want was a proto, got was (%T)", got)
 			return
 		}
-		if !proto.Equal(wantProto, gotProto) {
-			a.fail("got %v, want %v", got, want)
-		}
-		return
 	}
 
-	if !reflect.DeepEqual(got, want) {
-		a.fail("got %v, want %v", got, want)
+	if diff := equalDiff(want, got, opts); diff != "" {
+		a.fail("not equal (-want +got):\n%s", diff)
 	}
-
 }
 
 func (a *assertion) NotEmpty(gots ...any) {
@@ -228,11 +265,74 @@ func (a *assertion) CodeError(err error, code codes.Code) {
 	}
 
 	if s, ok := status.FromError(err); !ok {
+		a.recordError(err)
 		a.fail("got error %s (%T), want code %s", err, err, code)
 	} else {
 		if s.Code() != code {
+			a.recordError(err)
 			a.fail("got code %s, want %s", s.Code(), code)
 		}
 		return
 	}
 }
+
+// attemptFailure is raised by fatal assertions within an Eventually attempt to
+// end the attempt.
+type attemptFailure string
+
+func (a *assertion) Eventually(ctx context.Context, timeout, interval time.Duration, fn func(Assertion)) {
+	a.helper()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	attempts := 0
+	for {
+		attempts++
+		failure := a.attempt(fn)
+		if failure == "" {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				a.fail("not passing after %s (%d attempts): %s", timeout, attempts, failure)
+			} else {
+				a.fail("%s before passing (%d attempts): %s", ctx.Err(), attempts, failure)
+			}
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// attempt runs fn with an assertion which records failures rather than
+// failing the test, returning the failures, or an empty string if all
+// assertions passed.
+func (a *assertion) attempt(fn func(Assertion)) (failure string) {
+	failures := []string{}
+	trial := &assertion{
+		helper: a.helper,
+		fatal: func(args ...any) {
+			panic(attemptFailure(fmt.Sprint(args...)))
+		},
+		error: func(args ...any) {
+			failures = append(failures, fmt.Sprint(args...))
+		},
+		assertionParent: a,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			af, ok := r.(attemptFailure)
+			if !ok {
+				panic(r)
+			}
+			failures = append(failures, string(af))
+		}
+		failure = strings.Join(failures, "; ")
+	}()
+
+	fn(trial)
+	return ""
+}
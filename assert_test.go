@@ -1,8 +1,16 @@
 package flowtest
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pentops/flowtest/be"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type testWrap struct {
@@ -18,6 +26,10 @@ func (t *testWrap) Fatal(args ...any) {
 	t.message = fmt.Sprint(args...)
 }
 
+func (t *testWrap) Error(args ...any) {
+	t.Fatal(args...)
+}
+
 func TestEquals(t *testing.T) {
 
 	for _, tc := range []struct {
@@ -53,6 +65,131 @@ func TestEquals(t *testing.T) {
 	}
 }
 
+func TestEqualOptions(t *testing.T) {
+	type sub struct {
+		N int
+	}
+	type item struct {
+		SKU   string
+		Price float64
+		At    time.Time
+		Sub   *sub
+	}
+	now := time.Now()
+
+	for _, tc := range []struct {
+		name  string
+		want  any
+		got   any
+		opts  []EqualOption
+		equal bool
+	}{{
+		name: "struct diff",
+		want: item{SKU: "a", Price: 1},
+		got:  item{SKU: "b", Price: 1},
+	}, {
+		name:  "ignore struct field",
+		want:  item{SKU: "a", Price: 1},
+		got:   item{SKU: "b", Price: 1},
+		opts:  []EqualOption{IgnoreFields("SKU")},
+		equal: true,
+	}, {
+		name:  "ignore nested proto field",
+		want:  &descriptorpb.DescriptorProto{Name: proto.String("a"), Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("x"), JsonName: proto.String("x")}}},
+		got:   &descriptorpb.DescriptorProto{Name: proto.String("a"), Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("x"), JsonName: proto.String("y")}}},
+		opts:  []EqualOption{IgnoreFields("field.json_name")},
+		equal: true,
+	}, {
+		name: "proto diff",
+		want: &descriptorpb.DescriptorProto{Name: proto.String("a")},
+		got:  &descriptorpb.DescriptorProto{Name: proto.String("b")},
+	}, {
+		name: "nil and empty",
+		want: []string{},
+		got:  []string(nil),
+	}, {
+		name:  "equate empty",
+		want:  []string{},
+		got:   []string(nil),
+		opts:  []EqualOption{EquateEmpty()},
+		equal: true,
+	}, {
+		name:  "ignore order",
+		want:  []item{{SKU: "a"}, {SKU: "b"}},
+		got:   []item{{SKU: "b"}, {SKU: "a"}},
+		opts:  []EqualOption{IgnoreOrder()},
+		equal: true,
+	}, {
+		name:  "ignore order with pointer fields",
+		want:  []item{{SKU: "a", Sub: &sub{N: 1}}, {SKU: "a", Sub: &sub{N: 2}}},
+		got:   []item{{SKU: "a", Sub: &sub{N: 2}}, {SKU: "a", Sub: &sub{N: 1}}},
+		opts:  []EqualOption{IgnoreOrder()},
+		equal: true,
+	}, {
+		name:  "ignore order of values which print the same",
+		want:  []any{1, "1"},
+		got:   []any{"1", 1},
+		opts:  []EqualOption{IgnoreOrder()},
+		equal: true,
+	}, {
+		name: "ignore order with different elements",
+		want: []item{{SKU: "a", Sub: &sub{N: 1}}, {SKU: "a", Sub: &sub{N: 2}}},
+		got:  []item{{SKU: "a", Sub: &sub{N: 2}}, {SKU: "a", Sub: &sub{N: 2}}},
+		opts: []EqualOption{IgnoreOrder()},
+	}, {
+		name:  "ignore order with approx float",
+		want:  []float64{1.0, 1.005},
+		got:   []float64{1.004, 0.999},
+		opts:  []EqualOption{IgnoreOrder(), ApproxFloat(0.005)},
+		equal: true,
+	}, {
+		name:  "ignore proto order",
+		want:  &descriptorpb.EnumDescriptorProto{ReservedName: []string{"a", "b"}},
+		got:   &descriptorpb.EnumDescriptorProto{ReservedName: []string{"b", "a"}},
+		opts:  []EqualOption{IgnoreOrder()},
+		equal: true,
+	}, {
+		name:  "approx float",
+		want:  item{Price: 1.0},
+		got:   item{Price: 1.001},
+		opts:  []EqualOption{ApproxFloat(0.01)},
+		equal: true,
+	}, {
+		name:  "approx time",
+		want:  item{At: now},
+		got:   item{At: now.Add(time.Millisecond)},
+		opts:  []EqualOption{ApproxTime(time.Second)},
+		equal: true,
+	}, {
+		name:  "approx timestamp",
+		want:  timestamppb.New(now),
+		got:   timestamppb.New(now.Add(time.Millisecond)),
+		opts:  []EqualOption{ApproxTime(time.Second)},
+		equal: true,
+	}, {
+		name: "timestamp outside margin",
+		want: timestamppb.New(now),
+		got:  timestamppb.New(now.Add(time.Minute)),
+		opts: []EqualOption{ApproxTime(time.Second)},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWrap{}
+			a := &assertion{
+				fatal:  tw.Fatal,
+				helper: tw.Helper,
+			}
+			a.Equal(tc.want, tc.got, tc.opts...)
+			if tc.equal && tw.failed {
+				t.Errorf("unexpected failure: %s", tw.message)
+			} else if !tc.equal && !tw.failed {
+				t.Errorf("did not fail")
+			} else if !tc.equal && !strings.Contains(tw.message, "(-want +got)") {
+				t.Errorf("failure is not a diff: %s", tw.message)
+			}
+		})
+	}
+}
+
 func TestNotNilHappy(t *testing.T) {
 
 	type testStruct struct{}
@@ -95,3 +232,78 @@ func TestNotNilHappy(t *testing.T) {
 	}
 
 }
+
+func TestEventually(t *testing.T) {
+
+	t.Run("passes after retries", func(t *testing.T) {
+		tw := &testWrap{}
+		a := &assertion{
+			fatal:  tw.Fatal,
+			error:  tw.Fatal,
+			helper: tw.Helper,
+		}
+		attempts := 0
+		a.Eventually(context.Background(), time.Second, time.Millisecond, func(a Assertion) {
+			attempts++
+			a.Equal(3, attempts)
+		})
+		if tw.failed {
+			t.Fatalf("Eventually failed: %s", tw.message)
+		}
+		if attempts != 3 {
+			t.Errorf("got %d attempts, want 3", attempts)
+		}
+	})
+
+	t.Run("reports last failure", func(t *testing.T) {
+		tw := &testWrap{}
+		a := &assertion{
+			fatal:  tw.Fatal,
+			error:  tw.Fatal,
+			helper: tw.Helper,
+		}
+		a.Eventually(context.Background(), 20*time.Millisecond, time.Millisecond, func(a Assertion) {
+			a.Sub("check").Fatal("never passes")
+		})
+		if !tw.failed {
+			t.Fatal("Eventually did not fail")
+		}
+		if !strings.Contains(tw.message, "check: never passes") || !strings.Contains(tw.message, "attempts") {
+			t.Errorf("unexpected message: %s", tw.message)
+		}
+	})
+
+	t.Run("stops on cancel", func(t *testing.T) {
+		tw := &testWrap{}
+		a := &assertion{
+			fatal:  tw.Fatal,
+			error:  tw.Fatal,
+			helper: tw.Helper,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		a.Eventually(ctx, time.Hour, time.Millisecond, func(a Assertion) {
+			a.Error("never passes")
+		})
+		if !strings.Contains(tw.message, "context canceled") {
+			t.Errorf("unexpected message: %s", tw.message)
+		}
+	})
+}
+
+func TestOutcome(t *testing.T) {
+	tw := &testWrap{}
+	a := &assertion{
+		fatal:  tw.Fatal,
+		helper: tw.Helper,
+	}
+	a.T(be.Equal(1, 1))
+	if tw.failed {
+		t.Fatalf("unexpected failure: %s", tw.message)
+	}
+
+	a.Sub("order").T(be.Equal(1, 2).At("count"))
+	if tw.message != "order.count: got 2, want 1" {
+		t.Errorf("unexpected message %q", tw.message)
+	}
+}
@@ -6,7 +6,52 @@ import (
 	"golang.org/x/exp/constraints"
 )
 
-type Outcome string
+// Outcome is the failure of a matcher, nil when the matcher passed.
+type Outcome struct {
+	// Message describes the failure, usually including Want and Got.
+	Message string `json:"message"`
+
+	// Want and Got are the values compared, when the matcher compares values.
+	Want any `json:"want,omitempty"`
+	Got  any `json:"got,omitempty"`
+
+	// Diff is a multi line comparison of Want and Got, when available.
+	Diff string `json:"diff,omitempty"`
+
+	// Path locates the compared value within a larger document or message.
+	Path string `json:"path,omitempty"`
+}
+
+// String renders the outcome as a single message, prefixed by the path and
+// followed by the diff.
+func (o *Outcome) String() string {
+	if o == nil {
+		return ""
+	}
+	msg := o.Message
+	if o.Path != "" {
+		msg = fmt.Sprintf("%s: %s", o.Path, msg)
+	}
+	if o.Diff != "" {
+		msg = fmt.Sprintf("%s\n%s", msg, o.Diff)
+	}
+	return msg
+}
+
+// At returns a copy of the outcome located at path, prefixed to any existing
+// path. A nil outcome stays nil.
+func (o *Outcome) At(path string) *Outcome {
+	if o == nil {
+		return nil
+	}
+	located := *o
+	if located.Path == "" {
+		located.Path = path
+	} else {
+		located.Path = path + "." + located.Path
+	}
+	return &located
+}
 
 type Failer interface {
 	Fatal(args ...any)
@@ -19,46 +64,62 @@ func (o *Outcome) Run(t Failer, msg string, args ...any) {
 	if len(args) > 0 {
 		msg = fmt.Sprintf(msg, args...)
 	}
-	fullMsg := fmt.Sprintf("%s: %s", msg, *o)
+	fullMsg := fmt.Sprintf("%s: %s", msg, o)
 	t.Fatal(fullMsg)
 }
 
+// Failf returns an outcome with the formatted message, for matchers outside
+// of this package.
+func Failf(format string, args ...any) *Outcome {
+	return &Outcome{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
 func failf(format string, args ...any) *Outcome {
-	str := fmt.Sprintf(format, args...)
-	return (*Outcome)(&str)
+	return Failf(format, args...)
+}
+
+// mismatch returns an outcome comparing want and got.
+func mismatch(want, got any, format string, args ...any) *Outcome {
+	return &Outcome{
+		Message: fmt.Sprintf(format, args...),
+		Want:    want,
+		Got:     got,
+	}
 }
 
 func Equal[T comparable](want, got T) *Outcome {
 	if want == got {
 		return nil
 	}
-	return failf("got %v, want %v", got, want)
+	return mismatch(want, got, "got %v, want %v", got, want)
 }
 
 func GreaterThan[T constraints.Ordered](a, b T) *Outcome {
 	if a > b {
 		return nil
 	}
-	return failf("%v is not greater than %v", a, b)
+	return mismatch(b, a, "%v is not greater than %v", a, b)
 }
 
 func LessThan[T constraints.Ordered](a, b T) *Outcome {
 	if a < b {
 		return nil
 	}
-	return failf("%v is not less than %v", a, b)
+	return mismatch(b, a, "%v is not less than %v", a, b)
 }
 
 func GreaterThanOrEqual[T constraints.Ordered](a, b T) *Outcome {
 	if a >= b {
 		return nil
 	}
-	return failf("%v is not greater than or equal to %v", a, b)
+	return mismatch(b, a, "%v is not greater than or equal to %v", a, b)
 }
 
 func LessThanOrEqual[T constraints.Ordered](a, b T) *Outcome {
 	if a <= b {
 		return nil
 	}
-	return failf("%v is not less than or equal to %v", a, b)
+	return mismatch(b, a, "%v is not less than or equal to %v", a, b)
 }
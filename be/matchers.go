@@ -0,0 +1,185 @@
+package be
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Contains asserts that the slice has an element equal to want.
+func Contains[T comparable](slice []T, want T) *Outcome {
+	for _, got := range slice {
+		if got == want {
+			return nil
+		}
+	}
+	return mismatch(want, slice, "%v does not contain %v", slice, want)
+}
+
+// ContainsKey asserts that the map has the key.
+func ContainsKey[K comparable, V any](m map[K]V, key K) *Outcome {
+	if _, ok := m[key]; ok {
+		return nil
+	}
+	return failf("map has no key %v", key)
+}
+
+// ContainsEntry asserts that the map has the key with the value want.
+func ContainsEntry[K, V comparable](m map[K]V, key K, want V) *Outcome {
+	got, ok := m[key]
+	if !ok {
+		return failf("map has no key %v", key)
+	}
+	if got != want {
+		return mismatch(want, got, "key %v: got %v, want %v", key, got, want)
+	}
+	return nil
+}
+
+// ElementsMatch asserts that the slices have the same elements, the same
+// number of times, in any order.
+func ElementsMatch[T comparable](want, got []T) *Outcome {
+	counts := map[T]int{}
+	for _, val := range want {
+		counts[val]++
+	}
+	for _, val := range got {
+		counts[val]--
+	}
+
+	missing := []T{}
+	extra := []T{}
+	for _, val := range want {
+		if counts[val] > 0 {
+			missing = append(missing, val)
+			counts[val]--
+		}
+	}
+	for _, val := range got {
+		if counts[val] < 0 {
+			extra = append(extra, val)
+			counts[val]++
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return nil
+	}
+	return mismatch(want, got, "elements do not match, missing %v, extra %v", missing, extra)
+}
+
+// Len asserts that the slice, map, string, array or channel has n elements.
+func Len(got any, n int) *Outcome {
+	rv := reflect.ValueOf(got)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array, reflect.Chan:
+		if rv.Len() != n {
+			return mismatch(n, rv.Len(), "got length %d, want %d", rv.Len(), n)
+		}
+		return nil
+	default:
+		return failf("%T has no length", got)
+	}
+}
+
+// Zero asserts that got is nil or the zero value of its type.
+func Zero(got any) *Outcome {
+	if got == nil || reflect.ValueOf(got).IsZero() {
+		return nil
+	}
+	return mismatch(nil, got, "got %v, want zero value", got)
+}
+
+// ErrorIs asserts that err matches target, per errors.Is.
+func ErrorIs(err, target error) *Outcome {
+	if errors.Is(err, target) {
+		return nil
+	}
+	return mismatch(target, err, "got error %v, want %v", err, target)
+}
+
+// ErrorAs asserts that err matches target, per errors.As, which sets target.
+func ErrorAs(err error, target any) *Outcome {
+	if err == nil {
+		return failf("got no error, want %T", target)
+	}
+	if errors.As(err, target) {
+		return nil
+	}
+	return failf("got error %v (%T), want %T", err, err, target)
+}
+
+// Regexp asserts that got matches the regular expression.
+func Regexp(pattern string, got string) *Outcome {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return failf("invalid pattern %q: %s", pattern, err)
+	}
+	if re.MatchString(got) {
+		return nil
+	}
+	return mismatch(pattern, got, "%q does not match %q", got, pattern)
+}
+
+// WithinDuration asserts that got is within delta of want.
+func WithinDuration(want, got time.Time, delta time.Duration) *Outcome {
+	diff := got.Sub(want)
+	if diff.Abs() <= delta {
+		return nil
+	}
+	return mismatch(want, got, "got %s, %s from %s, want within %s", got, diff, want, delta)
+}
+
+// InDelta asserts that got is within delta of want.
+func InDelta[T constraints.Integer | constraints.Float](want, got, delta T) *Outcome {
+	diff := got - want
+	if got < want {
+		diff = want - got
+	}
+	if diff <= delta {
+		return nil
+	}
+	return mismatch(want, got, "got %v, want %v within %v", got, want, delta)
+}
+
+// All asserts that every outcome passed, reporting all of the failures.
+func All(outcomes ...*Outcome) *Outcome {
+	failures := []string{}
+	for _, outcome := range outcomes {
+		if outcome != nil {
+			failures = append(failures, outcome.String())
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return failf("%s", strings.Join(failures, "; "))
+}
+
+// Any asserts that at least one outcome passed.
+func Any(outcomes ...*Outcome) *Outcome {
+	failures := []string{}
+	for _, outcome := range outcomes {
+		if outcome == nil {
+			return nil
+		}
+		failures = append(failures, outcome.String())
+	}
+	return failf("none passed: %s", strings.Join(failures, "; "))
+}
+
+// Not asserts that the outcome failed. As a passing outcome has no
+// description, desc describes what should not have matched.
+func Not(outcome *Outcome, desc string, args ...any) *Outcome {
+	if outcome != nil {
+		return nil
+	}
+	if len(args) > 0 {
+		desc = fmt.Sprintf(desc, args...)
+	}
+	return failf("unexpectedly passed: %s", desc)
+}
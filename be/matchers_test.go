@@ -0,0 +1,70 @@
+package be
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchers(t *testing.T) {
+	now := time.Now()
+	wrapped := fmt.Errorf("reading: %w", fs.ErrNotExist)
+	pathErr := &fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}
+
+	for _, tc := range []struct {
+		name    string
+		outcome *Outcome
+		failure string
+	}{
+		{"contains", Contains([]string{"a", "b"}, "b"), ""},
+		{"not contains", Contains([]string{"a", "b"}, "c"), "[a b] does not contain c"},
+		{"contains key", ContainsKey(map[string]int{"a": 1}, "a"), ""},
+		{"missing key", ContainsKey(map[string]int{"a": 1}, "b"), "map has no key b"},
+		{"contains entry", ContainsEntry(map[string]int{"a": 1}, "a", 1), ""},
+		{"wrong entry", ContainsEntry(map[string]int{"a": 1}, "a", 2), "key a: got 1, want 2"},
+		{"elements match", ElementsMatch([]int{1, 2, 2}, []int{2, 1, 2}), ""},
+		{"elements differ", ElementsMatch([]int{1, 2, 2}, []int{2, 1, 3}), "missing [2], extra [3]"},
+		{"len slice", Len([]int{1, 2}, 2), ""},
+		{"len map", Len(map[string]int{"a": 1}, 2), "got length 1, want 2"},
+		{"len of int", Len(1, 1), "int has no length"},
+		{"zero", Zero(""), ""},
+		{"zero nil", Zero(nil), ""},
+		{"not zero", Zero(1), "got 1, want zero value"},
+		{"error is", ErrorIs(wrapped, fs.ErrNotExist), ""},
+		{"error is not", ErrorIs(wrapped, fs.ErrExist), "want file already exists"},
+		{"error as", ErrorAs(fmt.Errorf("wrapped: %w", pathErr), new(*fs.PathError)), ""},
+		{"error not as", ErrorAs(wrapped, new(*fs.PathError)), "want **fs.PathError"},
+		{"error as nil", ErrorAs(nil, new(*fs.PathError)), "got no error"},
+		{"regexp", Regexp(`^order-\d+$`, "order-12"), ""},
+		{"regexp mismatch", Regexp(`^order-\d+$`, "item-12"), `"item-12" does not match`},
+		{"regexp invalid", Regexp(`(`, "x"), "invalid pattern"},
+		{"within duration", WithinDuration(now, now.Add(-time.Second), 2*time.Second), ""},
+		{"outside duration", WithinDuration(now, now.Add(time.Minute), time.Second), "want within 1s"},
+		{"in delta", InDelta(1.0, 1.05, 0.1), ""},
+		{"in delta unsigned", InDelta[uint](5, 3, 2), ""},
+		{"outside delta", InDelta(10, 13, 2), "got 13, want 10 within 2"},
+		{"all", All(Equal(1, 1), Contains([]int{1}, 1)), ""},
+		{"all fails", All(Equal(1, 2), Equal(1, 1), Equal("a", "b")), "got 2, want 1; got b, want a"},
+		{"any", Any(Equal(1, 2), Equal(1, 1)), ""},
+		{"any fails", Any(Equal(1, 2), Equal(1, 3)), "none passed: got 2, want 1; got 3, want 1"},
+		{"not", Not(Equal(1, 2), "equal to %d", 1), ""},
+		{"not fails", Not(Equal(1, 1), "equal to %d", 1), "unexpectedly passed: equal to 1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.failure == "" {
+				if tc.outcome != nil {
+					t.Errorf("unexpected failure: %s", tc.outcome)
+				}
+				return
+			}
+			if tc.outcome == nil {
+				t.Fatal("expected failure, but got none")
+			}
+			if !strings.Contains(tc.outcome.String(), tc.failure) {
+				t.Errorf("got failure %q, want %q", tc.outcome, tc.failure)
+			}
+		})
+	}
+}
@@ -16,7 +16,7 @@ func TestHappyFailFuncs(t *testing.T) {
 		LessThan(1, 2),
 	} {
 		if tc != nil {
-			t.Errorf("failed: %s", *tc)
+			t.Errorf("failed: %s", tc)
 		}
 	}
 
@@ -34,3 +34,27 @@ func TestSadFailFuncs(t *testing.T) {
 	}
 
 }
+
+func TestOutcomeString(t *testing.T) {
+	outcome := Equal(1, 2)
+	if outcome.Want != 1 || outcome.Got != 2 {
+		t.Errorf("unexpected values %v, %v", outcome.Want, outcome.Got)
+	}
+	if got := outcome.String(); got != "got 2, want 1" {
+		t.Errorf("unexpected string %q", got)
+	}
+
+	located := outcome.At("items[0]").At("order")
+	located.Diff = "-1\n+2"
+	if got := located.String(); got != "order.items[0]: got 2, want 1\n-1\n+2" {
+		t.Errorf("unexpected string %q", got)
+	}
+	if outcome.Path != "" {
+		t.Error("At modified the original outcome")
+	}
+
+	var passed *Outcome
+	if passed.At("order") != nil || passed.String() != "" {
+		t.Error("nil outcome should stay nil")
+	}
+}
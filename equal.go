@@ -0,0 +1,180 @@
+package flowtest
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// EqualOption changes how Equal compares values.
+type EqualOption func(*equalConfig)
+
+type equalConfig struct {
+	options     []cmp.Option
+	ignoreOrder bool
+}
+
+// IgnoreFields ignores the fields at the given dot separated paths. Proto
+// fields use the proto field name, struct fields the Go field name, e.g.
+// "order.created_at" or "Order.CreatedAt". List indexes are not part of the
+// path, so "items.sku" ignores the sku of every item.
+func IgnoreFields(paths ...string) EqualOption {
+	return func(c *equalConfig) {
+		c.options = append(c.options, cmp.FilterPath(func(path cmp.Path) bool {
+			name := fieldPath(path)
+			for _, ignore := range paths {
+				if name == ignore {
+					return true
+				}
+			}
+			return false
+		}, cmp.Ignore()))
+	}
+}
+
+// EquateEmpty treats nil and empty slices and maps as equal.
+func EquateEmpty() EqualOption {
+	return func(c *equalConfig) {
+		c.options = append(c.options, cmpopts.EquateEmpty())
+	}
+}
+
+// IgnoreOrder compares every list, including repeated proto fields, without
+// regard to the order of the elements. Each element of one list must be equal
+// to a different element of the other, using the same options.
+func IgnoreOrder() EqualOption {
+	return func(c *equalConfig) {
+		c.ignoreOrder = true
+	}
+}
+
+// unorderedComparer compares slices as multisets, pairing up the elements
+// which are equal under the options. Slices of different lengths, or with a
+// single element, are left to cmp so that the diff shows the elements.
+func unorderedComparer(options func() []cmp.Option) cmp.Option {
+	return cmp.FilterValues(func(a, b any) bool {
+		va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+		return va.Kind() == reflect.Slice && va.Type() == vb.Type() &&
+			va.Len() == vb.Len() && va.Len() > 1
+	}, cmp.Comparer(func(a, b any) bool {
+		va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+		equal := make([][]bool, va.Len())
+		for i := range equal {
+			equal[i] = make([]bool, vb.Len())
+			for j := range equal[i] {
+				equal[i][j] = cmp.Equal(va.Index(i).Interface(), vb.Index(j).Interface(), options()...)
+			}
+		}
+		return matchAll(equal)
+	}))
+}
+
+// matchAll reports whether every row can be paired with a different column
+// where equal is true, finding augmenting paths as approximate options do
+// not make equality transitive.
+func matchAll(equal [][]bool) bool {
+	matchedRow := make([]int, len(equal))
+	for j := range matchedRow {
+		matchedRow[j] = -1
+	}
+	var augment func(i int, seen []bool) bool
+	augment = func(i int, seen []bool) bool {
+		for j, ok := range equal[i] {
+			if !ok || seen[j] {
+				continue
+			}
+			seen[j] = true
+			if matchedRow[j] < 0 || augment(matchedRow[j], seen) {
+				matchedRow[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range equal {
+		if !augment(i, make([]bool, len(equal))) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApproxFloat treats float values as equal when they are within margin of
+// each other.
+func ApproxFloat(margin float64) EqualOption {
+	return func(c *equalConfig) {
+		c.options = append(c.options, cmpopts.EquateApprox(0, margin))
+	}
+}
+
+// ApproxTime treats time.Time values, and google.protobuf.Timestamp messages,
+// as equal when they are within margin of each other.
+func ApproxTime(margin time.Duration) EqualOption {
+	return func(c *equalConfig) {
+		c.options = append(c.options,
+			cmpopts.EquateApproxTime(margin),
+			cmp.FilterValues(func(a, b protocmp.Message) bool {
+				return isTimestamp(a) && isTimestamp(b)
+			}, cmp.Comparer(func(a, b protocmp.Message) bool {
+				diff := timestampTime(a).Sub(timestampTime(b))
+				return diff.Abs() <= margin
+			})),
+		)
+	}
+}
+
+const timestampName protoreflect.FullName = "google.protobuf.Timestamp"
+
+func isTimestamp(msg protocmp.Message) bool {
+	return msg != nil && msg.Descriptor().FullName() == timestampName
+}
+
+func timestampTime(msg protocmp.Message) time.Time {
+	refl := msg.Unwrap().ProtoReflect()
+	fields := refl.Descriptor().Fields()
+	seconds := refl.Get(fields.ByName("seconds")).Int()
+	nanos := refl.Get(fields.ByName("nanos")).Int()
+	return time.Unix(seconds, nanos)
+}
+
+// fieldPath renders the struct fields and proto fields of the path, separated
+// by dots, skipping list indexes.
+func fieldPath(path cmp.Path) string {
+	names := []string{}
+	for _, step := range path {
+		switch step := step.(type) {
+		case cmp.StructField:
+			names = append(names, step.Name())
+		case cmp.MapIndex:
+			// Messages transformed by protocmp are maps keyed by field name.
+			if step.Key().Kind() == reflect.String {
+				names = append(names, step.Key().String())
+			}
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+func equalDiff(want, got any, opts []EqualOption) string {
+	config := &equalConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	options := append([]cmp.Option{
+		protocmp.Transform(),
+		cmp.Exporter(func(reflect.Type) bool { return true }),
+	}, config.options...)
+	if config.ignoreOrder {
+		// Elements are compared with all of the options, including this one
+		// for nested lists.
+		options = append(options, unorderedComparer(func() []cmp.Option {
+			return options
+		}))
+	}
+	return cmp.Diff(want, got, options...)
+}
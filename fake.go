@@ -0,0 +1,172 @@
+package flowtest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FakeResponse produces the response of a FakeService method for a request.
+type FakeResponse func(ctx context.Context, req proto.Message) (proto.Message, error)
+
+// Return responds with a copy of res.
+func Return(res proto.Message) FakeResponse {
+	return func(context.Context, proto.Message) (proto.Message, error) {
+		return proto.Clone(res), nil
+	}
+}
+
+// ReturnError responds with the error, usually created with status.Error.
+func ReturnError(err error) FakeResponse {
+	return func(context.Context, proto.Message) (proto.Message, error) {
+		return nil, err
+	}
+}
+
+// FakeCall is a request received by a FakeService.
+type FakeCall struct {
+	// Method is the name of the method within the service, e.g. "GetFoo".
+	Method string
+
+	Request  proto.Message
+	Metadata metadata.MD
+}
+
+type fakeMethod struct {
+	responses []FakeResponse
+	calls     int
+}
+
+// FakeService serves any service from its descriptor, standing in for a
+// dependency of the service under test. Each method responds as programmed
+// with Respond, and every call is recorded until Reset.
+type FakeService struct {
+	service protoreflect.ServiceDescriptor
+
+	lock    sync.Mutex
+	methods map[string]*fakeMethod
+	calls   []*FakeCall
+}
+
+// NewFakeService registers a fake of the service on the server, which must
+// not be serving yet. Only unary methods are served.
+func NewFakeService(server *grpc.Server, service protoreflect.ServiceDescriptor) *FakeService {
+	fs := &FakeService{
+		service: service,
+		methods: map[string]*fakeMethod{},
+	}
+	RegisterDynamicService(server, service, fs.handle)
+	return fs
+}
+
+// Respond programs the responses of the method. A single response answers
+// every call. Multiple responses answer the calls in sequence, after which
+// calls fail with codes.Internal. Respond replaces any previous responses and
+// restarts the sequence. Calls to methods without responses fail with
+// codes.Unimplemented.
+func (fs *FakeService) Respond(method string, responses ...FakeResponse) {
+	if fs.service.Methods().ByName(protoreflect.Name(method)) == nil {
+		panic(fmt.Sprintf("fake %s has no method %q", fs.service.FullName(), method))
+	}
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.methods[method] = &fakeMethod{
+		responses: responses,
+	}
+}
+
+func (fs *FakeService) handle(ctx context.Context, method protoreflect.MethodDescriptor, req proto.Message) (proto.Message, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	name := string(method.Name())
+
+	fs.lock.Lock()
+	fs.calls = append(fs.calls, &FakeCall{
+		Method:   name,
+		Request:  req,
+		Metadata: md,
+	})
+	programmed, ok := fs.methods[name]
+	var response FakeResponse
+	if ok {
+		switch {
+		case len(programmed.responses) == 1:
+			response = programmed.responses[0]
+		case programmed.calls < len(programmed.responses):
+			response = programmed.responses[programmed.calls]
+		}
+		programmed.calls++
+	}
+	fs.lock.Unlock()
+
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "fake %s: no response for %s", fs.service.FullName(), name)
+	}
+	if response == nil {
+		return nil, status.Errorf(codes.Internal, "fake %s: no response for call %d to %s, %d programmed", fs.service.FullName(), programmed.calls, name, len(programmed.responses))
+	}
+
+	res, err := response(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return newMessage(method.Output()), nil
+	}
+	if got := res.ProtoReflect().Descriptor().FullName(); got != method.Output().FullName() {
+		return nil, status.Errorf(codes.Internal, "fake %s: response to %s is %s, want %s", fs.service.FullName(), name, got, method.Output().FullName())
+	}
+	return res, nil
+}
+
+// Calls returns the calls received since the last Reset, in order. When
+// methods are given, only calls to those methods are returned.
+func (fs *FakeService) Calls(methods ...string) []*FakeCall {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	calls := make([]*FakeCall, 0, len(fs.calls))
+	for _, call := range fs.calls {
+		if len(methods) == 0 || slices.Contains(methods, call.Method) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls and restarts response sequences. The
+// programmed responses are kept.
+func (fs *FakeService) Reset() {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	fs.calls = nil
+	for _, method := range fs.methods {
+		method.calls = 0
+	}
+}
+
+// ResetHook resets the fake, for use as a PreStepHook.
+func (fs *FakeService) ResetHook(ctx context.Context, a Asserter) error {
+	fs.Reset()
+	return nil
+}
+
+// AssertCalls asserts that the methods, and only those, were called since the
+// last Reset, in the order given.
+func (fs *FakeService) AssertCalls(a Assertion, methods ...string) {
+	a.Helper()
+	calls := fs.Calls()
+	got := make([]string, 0, len(calls))
+	for _, call := range calls {
+		got = append(got, call.Method)
+	}
+	if !slices.Equal(got, methods) {
+		a.Fatalf("fake %s got calls %v, want %v", fs.service.FullName(), got, methods)
+	}
+}
@@ -0,0 +1,97 @@
+package flowtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestFakeService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := testService(t, "FooService",
+		"google.protobuf.StringValue", "google.protobuf.StringValue",
+		"GetFoo", "ListFoos", "DeleteFoo")
+
+	pair := NewGRPCPair(t)
+	fake := NewFakeService(pair.Server, service)
+	pair.ServeUntilDone(t, ctx)
+
+	call := func(method string, req string) (string, error) {
+		res := &wrapperspb.StringValue{}
+		err := pair.Client.Invoke(ctx, "/test.FooService/"+method, wrapperspb.String(req), res)
+		return res.Value, err
+	}
+
+	fake.Respond("GetFoo", Return(wrapperspb.String("static")))
+	fake.Respond("ListFoos",
+		Return(wrapperspb.String("first")),
+		ReturnError(status.Error(codes.Unavailable, "second")),
+		func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			return wrapperspb.String("third " + req.(*wrapperspb.StringValue).Value), nil
+		},
+	)
+
+	for i := 0; i < 2; i++ {
+		got, err := call("GetFoo", "a")
+		if err != nil || got != "static" {
+			t.Errorf("GetFoo got %q, %v", got, err)
+		}
+	}
+
+	if got, err := call("ListFoos", "b"); err != nil || got != "first" {
+		t.Errorf("ListFoos 1 got %q, %v", got, err)
+	}
+	if _, err := call("ListFoos", "b"); status.Code(err) != codes.Unavailable {
+		t.Errorf("ListFoos 2 got %v", err)
+	}
+	if got, err := call("ListFoos", "b"); err != nil || got != "third b" {
+		t.Errorf("ListFoos 3 got %q, %v", got, err)
+	}
+	if _, err := call("ListFoos", "b"); status.Code(err) != codes.Internal {
+		t.Errorf("ListFoos 4 got %v", err)
+	}
+	if _, err := call("DeleteFoo", "c"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("DeleteFoo got %v", err)
+	}
+
+	if got := len(fake.Calls("ListFoos")); got != 4 {
+		t.Errorf("got %d ListFoos calls", got)
+	}
+	if got := fake.Calls("GetFoo")[0].Request.(*wrapperspb.StringValue).Value; got != "a" {
+		t.Errorf("got request %q", got)
+	}
+
+	tw := &testWrap{}
+	fake.AssertCalls(&assertion{fatal: tw.Fatal, helper: tw.Helper, assertionParent: tw},
+		"GetFoo", "GetFoo", "ListFoos", "ListFoos", "ListFoos", "ListFoos", "DeleteFoo")
+	if tw.failed {
+		t.Errorf("unexpected failure %s", tw.message)
+	}
+
+	tw = &testWrap{}
+	fake.AssertCalls(&assertion{fatal: tw.Fatal, helper: tw.Helper, assertionParent: tw}, "GetFoo")
+	if !tw.failed || !strings.Contains(tw.message, "want [GetFoo]") {
+		t.Errorf("unexpected failure %q", tw.message)
+	}
+
+	fake.Reset()
+	if got, err := call("ListFoos", "b"); err != nil || got != "first" {
+		t.Errorf("ListFoos after reset got %q, %v", got, err)
+	}
+	if got := len(fake.Calls()); got != 1 {
+		t.Errorf("got %d calls after reset", got)
+	}
+
+	fake.Respond("GetFoo", Return(&emptypb.Empty{}))
+	if _, err := call("GetFoo", "a"); status.Code(err) != codes.Internal {
+		t.Errorf("GetFoo with wrong response type got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Package golden compares documents against golden files under testdata,
+// for expected messages which are too large to write out by hand.
+//
+// Golden files are canonical JSON, with sorted keys and two space indents,
+// so that they diff well in review. Protos are encoded with protojson, as the
+// prototext output is deliberately unstable between builds.
+//
+// Run the tests with -golden.update, or with FLOWTEST_UPDATE_GOLDEN=1 in the
+// environment, to write the golden files from the current output.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pentops/flowtest/jsontest"
+)
+
+// UpdateEnvVar is the environment variable which, when set to a true value,
+// rewrites golden files rather than comparing against them.
+const UpdateEnvVar = "FLOWTEST_UPDATE_GOLDEN"
+
+var updateFlag = flag.Bool("golden.update", false, "rewrite golden files rather than comparing against them")
+
+// MaskedValue replaces the masked fields in golden files and compared
+// documents.
+const MaskedValue = "<masked>"
+
+type TB interface {
+	Fatalf(format string, args ...any)
+	Errorf(format string, args ...any)
+	Log(args ...any)
+	Helper()
+}
+
+type config struct {
+	dir    string
+	masks  [][]string
+	update bool
+}
+
+// Option configures a golden file assertion.
+type Option func(*config)
+
+// Mask replaces the values at the given paths with MaskedValue, for volatile
+// fields like IDs and timestamps. Paths are dot separated keys, using the JSON
+// field names of protos, where # matches every element of an array and *
+// matches every key or element, e.g. "order.id" or "order.items.#.createdAt".
+// Paths which are not present are ignored.
+func Mask(paths ...string) Option {
+	return func(c *config) {
+		for _, path := range paths {
+			c.masks = append(c.masks, strings.Split(path, "."))
+		}
+	}
+}
+
+// Dir sets the directory of the golden files, testdata by default.
+func Dir(dir string) Option {
+	return func(c *config) {
+		c.dir = dir
+	}
+}
+
+// Update rewrites the golden file when update is true, in addition to the
+// flag and environment variable.
+func Update(update bool) Option {
+	return func(c *config) {
+		c.update = c.update || update
+	}
+}
+
+func shouldUpdate() bool {
+	if *updateFlag {
+		return true
+	}
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnvVar))
+	return update
+}
+
+// Assert compares v against the golden file testdata/<name>.golden.json. v is
+// a proto message, a *jsontest.Asserter, a JSON string or []byte, or any other
+// value which encodes to JSON.
+func Assert(t TB, name string, v any, opts ...Option) {
+	t.Helper()
+	cfg := &config{
+		dir:    "testdata",
+		update: shouldUpdate(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	got, err := canonical(v, cfg.masks)
+	if err != nil {
+		t.Fatalf("golden %s: %s", name, err)
+		return
+	}
+
+	path := filepath.Join(cfg.dir, name+".golden.json")
+
+	if cfg.update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden %s: %s", name, err)
+			return
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("golden %s: %s", name, err)
+			return
+		}
+		t.Log(fmt.Sprintf("updated golden file %s", path))
+		return
+	}
+
+	wantFile, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist, run with -golden.update or %s=1 to create it", path, UpdateEnvVar)
+		return
+	} else if err != nil {
+		t.Fatalf("golden %s: %s", name, err)
+		return
+	}
+
+	want, err := canonical(wantFile, cfg.masks)
+	if err != nil {
+		t.Fatalf("golden file %s: %s", path, err)
+		return
+	}
+
+	if bytes.Equal(want, got) {
+		return
+	}
+
+	var wantDoc, gotDoc any
+	_ = json.Unmarshal(want, &wantDoc)
+	_ = json.Unmarshal(got, &gotDoc)
+	t.Errorf("does not match golden file %s (-want +got):\n%s", path, cmp.Diff(wantDoc, gotDoc))
+}
+
+// canonical encodes v as JSON with sorted keys, indented, and masked.
+func canonical(v any, masks [][]string) ([]byte, error) {
+	var raw string
+	if asserter, ok := v.(*jsontest.Asserter); ok {
+		raw = asserter.JSON
+	} else {
+		asserter, err := jsontest.NewAsserter(v)
+		if err != nil {
+			return nil, err
+		}
+		raw = asserter.JSON
+	}
+
+	var doc any
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+
+	for _, mask := range masks {
+		doc = maskPath(doc, mask)
+	}
+
+	out := &bytes.Buffer{}
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// maskPath returns doc with the values at path replaced.
+func maskPath(doc any, path []string) any {
+	if len(path) == 0 {
+		return MaskedValue
+	}
+	key, rest := path[0], path[1:]
+	switch doc := doc.(type) {
+	case map[string]any:
+		for k, v := range doc {
+			if key == "*" || key == k {
+				doc[k] = maskPath(v, rest)
+			}
+		}
+	case []any:
+		if key == "#" || key == "*" {
+			for idx, v := range doc {
+				doc[idx] = maskPath(v, rest)
+			}
+		} else if idx, err := strconv.Atoi(key); err == nil && idx >= 0 && idx < len(doc) {
+			doc[idx] = maskPath(doc[idx], rest)
+		}
+	}
+	return doc
+}
@@ -0,0 +1,105 @@
+package golden
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pentops/flowtest/jsontest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type captureTB struct {
+	failures []string
+	logs     []string
+}
+
+func (t *captureTB) Helper() {}
+
+func (t *captureTB) Fatalf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *captureTB) Errorf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *captureTB) Log(args ...any) {
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
+
+func TestAssert(t *testing.T) {
+	dir := t.TempDir()
+	msg := &descriptorpb.DescriptorProto{
+		Name: proto.String("Order"),
+		Field: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("id"),
+			JsonName: proto.String("generated-1"),
+		}, {
+			Name:     proto.String("sku"),
+			JsonName: proto.String("generated-2"),
+		}},
+	}
+	mask := Mask("field.#.jsonName")
+
+	missing := &captureTB{}
+	Assert(missing, "order", msg, Dir(dir), mask)
+	if len(missing.failures) != 1 || !strings.Contains(missing.failures[0], "does not exist") {
+		t.Fatalf("unexpected failures for a missing file %q", missing.failures)
+	}
+
+	update := &captureTB{}
+	Assert(update, "order", msg, Dir(dir), mask, Update(true))
+	if len(update.failures) > 0 {
+		t.Fatalf("unexpected failures updating %q", update.failures)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "order.golden.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "field": [
+    {
+      "jsonName": "<masked>",
+      "name": "id"
+    },
+    {
+      "jsonName": "<masked>",
+      "name": "sku"
+    }
+  ],
+  "name": "Order"
+}
+`
+	if string(written) != want {
+		t.Errorf("unexpected golden file:\n%s", written)
+	}
+
+	// The masked field changes, and the document is passed as JSON.
+	msg.Field[0].JsonName = proto.String("generated-3")
+	matches := &captureTB{}
+	asserter, err := jsontest.NewAsserter(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Assert(matches, "order", asserter, Dir(dir), mask)
+	if len(matches.failures) > 0 {
+		t.Errorf("unexpected failures %q", matches.failures)
+	}
+
+	msg.Field[1].Name = proto.String("code")
+	mismatch := &captureTB{}
+	Assert(mismatch, "order", msg, Dir(dir), mask)
+	if len(mismatch.failures) != 1 {
+		t.Fatalf("unexpected failures %q", mismatch.failures)
+	}
+	for _, want := range []string{"does not match golden file", `-`, `"sku"`, `+`, `"code"`} {
+		if !strings.Contains(mismatch.failures[0], want) {
+			t.Errorf("diff does not contain %q:\n%s", want, mismatch.failures[0])
+		}
+	}
+}
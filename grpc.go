@@ -2,25 +2,92 @@ package flowtest
 
 import (
 	"context"
+	"fmt"
 	"net"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
+type grpcPairConfig struct {
+	unaryServer  []grpc.UnaryServerInterceptor
+	streamServer []grpc.StreamServerInterceptor
+	unaryClient  []grpc.UnaryClientInterceptor
+	streamClient []grpc.StreamClientInterceptor
+}
+
+// GRPCPairOption configures the server and client of a GRPCPair.
+type GRPCPairOption func(*grpcPairConfig)
+
+// WithUnaryServerInterceptors adds unary interceptors to the server, run in
+// the order given.
+func WithUnaryServerInterceptors(interceptors ...grpc.UnaryServerInterceptor) GRPCPairOption {
+	return func(c *grpcPairConfig) {
+		c.unaryServer = append(c.unaryServer, interceptors...)
+	}
+}
+
+// WithStreamServerInterceptors adds stream interceptors to the server, run in
+// the order given.
+func WithStreamServerInterceptors(interceptors ...grpc.StreamServerInterceptor) GRPCPairOption {
+	return func(c *grpcPairConfig) {
+		c.streamServer = append(c.streamServer, interceptors...)
+	}
+}
+
+// WithUnaryClientInterceptors adds unary interceptors to the client
+// connection, run in the order given.
+func WithUnaryClientInterceptors(interceptors ...grpc.UnaryClientInterceptor) GRPCPairOption {
+	return func(c *grpcPairConfig) {
+		c.unaryClient = append(c.unaryClient, interceptors...)
+	}
+}
+
+// WithStreamClientInterceptors adds stream interceptors to the client
+// connection, run in the order given.
+func WithStreamClientInterceptors(interceptors ...grpc.StreamClientInterceptor) GRPCPairOption {
+	return func(c *grpcPairConfig) {
+		c.streamClient = append(c.streamClient, interceptors...)
+	}
+}
+
+// NewGRPCPair creates a server and client connected over an in-memory
+// listener, with the given unary middleware on the server.
 func NewGRPCPair(t TB, middleware ...grpc.UnaryServerInterceptor) *GRPCPair {
+	t.Helper()
+	return NewGRPCPairWithOptions(t, WithUnaryServerInterceptors(middleware...))
+}
+
+// NewGRPCPairWithOptions creates a server and client connected over an
+// in-memory listener, allowing interceptors for both streaming and unary calls
+// on both sides of the connection.
+func NewGRPCPairWithOptions(t TB, options ...GRPCPairOption) *GRPCPair {
+	t.Helper()
+	config := &grpcPairConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+
 	lis := bufconn.Listen(1024 * 1024)
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(middleware...)),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(config.unaryServer...)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(config.streamServer...)),
 	)
 
 	conn, err := grpc.NewClient("127.0.0.1",
 		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
 			return lis.Dial()
 		}),
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(config.unaryClient...)),
+		grpc.WithStreamInterceptor(grpc_middleware.ChainStreamClient(config.streamClient...)),
+	)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,3 +120,56 @@ func (gg *GRPCPair) ServeUntilDone(t TB, ctx context.Context) {
 		gg.listener.Close()
 	}()
 }
+
+// DynamicUnaryHandler handles any unary method of a service registered with
+// RegisterDynamicService. req is the generated Go type of the method input
+// when it is linked into the binary, otherwise a dynamicpb message.
+type DynamicUnaryHandler func(ctx context.Context, method protoreflect.MethodDescriptor, req proto.Message) (proto.Message, error)
+
+// newMessage returns an empty message of the generated Go type, or a dynamic
+// message when the type is not linked into the binary.
+func newMessage(desc protoreflect.MessageDescriptor) proto.Message {
+	msgType, err := protoregistry.GlobalTypes.FindMessageByName(desc.FullName())
+	if err == nil {
+		return msgType.New().Interface()
+	}
+	return dynamicpb.NewMessage(desc)
+}
+
+// RegisterDynamicService registers every unary method of the service on the
+// server, without generated service code, calling handler for each request.
+// Streaming methods are not registered.
+func RegisterDynamicService(server *grpc.Server, service protoreflect.ServiceDescriptor, handler DynamicUnaryHandler) {
+	desc := &grpc.ServiceDesc{
+		ServiceName: string(service.FullName()),
+		HandlerType: (*any)(nil),
+		Metadata:    service.ParentFile().Path(),
+	}
+
+	methods := service.Methods()
+	for idx := 0; idx < methods.Len(); idx++ {
+		method := methods.Get(idx)
+		if method.IsStreamingClient() || method.IsStreamingServer() {
+			continue
+		}
+		fullMethod := fmt.Sprintf("/%s/%s", service.FullName(), method.Name())
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: string(method.Name()),
+			Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				req := newMessage(method.Input())
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				call := func(ctx context.Context, req any) (any, error) {
+					return handler(ctx, method, req.(proto.Message))
+				}
+				if interceptor == nil {
+					return call(ctx, req)
+				}
+				return interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: fullMethod}, call)
+			},
+		})
+	}
+
+	server.RegisterService(desc, nil)
+}
@@ -2,8 +2,16 @@ package flowtest
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/interop/grpc_testing"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/reflection/grpc_reflection_v1"
 )
@@ -27,3 +35,238 @@ func TestGrpcPair(t *testing.T) {
 	t.Logf("resp: %v", resp)
 
 }
+
+func TestGrpcPairInterceptors(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	called := map[string]int{}
+	pair := NewGRPCPairWithOptions(t,
+		WithUnaryServerInterceptors(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			called["unaryServer"]++
+			return handler(ctx, req)
+		}),
+		WithStreamServerInterceptors(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			called["streamServer"]++
+			return handler(srv, ss)
+		}),
+		WithUnaryClientInterceptors(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			called["unaryClient"]++
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+		WithStreamClientInterceptors(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			called["streamClient"]++
+			return streamer(ctx, desc, cc, method, opts...)
+		}),
+	)
+
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(pair.Server, healthServer)
+	pair.ServeUntilDone(t, ctx)
+
+	client := grpc_health_v1.NewHealthClient(pair.Client)
+	if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"unaryServer", "streamServer", "unaryClient", "streamClient"} {
+		if called[key] != 1 {
+			t.Errorf("%s called %d times, want 1", key, called[key])
+		}
+	}
+}
+
+type captureLogger struct {
+	lock    sync.Mutex
+	entries []any
+}
+
+func (cl *captureLogger) Log(args ...any) {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	cl.entries = append(cl.entries, args...)
+}
+
+func TestGrpcPairLogger(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &captureLogger{}
+	pair := NewGRPCPairWithOptions(t, WithGRPCLogger(logger))
+
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(pair.Server, healthServer)
+	pair.ServeUntilDone(t, ctx)
+
+	client := grpc_health_v1.NewHealthClient(pair.Client)
+	if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "foo"}); err == nil {
+		t.Fatal("expected error for unknown service")
+	}
+
+	if len(logger.entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(logger.entries))
+	}
+	entry, ok := logger.entries[0].(*GRPCLog)
+	if !ok {
+		t.Fatalf("got log entry %T, want *GRPCLog", logger.entries[0])
+	}
+	if entry.Method != "/grpc.health.v1.Health/Check" {
+		t.Errorf("got method %q", entry.Method)
+	}
+	if entry.Code != codes.NotFound {
+		t.Errorf("got code %s, want NotFound", entry.Code)
+	}
+	if len(entry.Requests) != 1 || !strings.Contains(string(entry.Requests[0]), "foo") {
+		t.Errorf("request not captured: %v", entry.Requests)
+	}
+	t.Log(entry)
+}
+
+type streamingInputServer struct {
+	grpc_testing.UnimplementedTestServiceServer
+}
+
+func (streamingInputServer) StreamingInputCall(stream grpc_testing.TestService_StreamingInputCallServer) error {
+	size := int32(0)
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return stream.SendAndClose(&grpc_testing.StreamingInputCallResponse{
+				AggregatedPayloadSize: size,
+			})
+		}
+		size += int32(len(req.Payload.GetBody()))
+	}
+}
+
+func (cl *captureLogger) grpcLogs() []*GRPCLog {
+	cl.lock.Lock()
+	defer cl.lock.Unlock()
+	logs := []*GRPCLog{}
+	for _, entry := range cl.entries {
+		if log, ok := entry.(*GRPCLog); ok {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+func TestGrpcPairStreamLogger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &captureLogger{}
+	pair := NewGRPCPairWithOptions(t, WithGRPCLogger(logger))
+
+	grpc_testing.RegisterTestServiceServer(pair.Server, streamingInputServer{})
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(pair.Server, healthServer)
+	pair.ServeUntilDone(t, ctx)
+
+	t.Run("client stream", func(t *testing.T) {
+		logger.entries = nil
+		stream, err := grpc_testing.NewTestServiceClient(pair.Client).StreamingInputCall(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, body := range []string{"ab", "cde"} {
+			if err := stream.Send(&grpc_testing.StreamingInputCallRequest{
+				Payload: &grpc_testing.Payload{Body: []byte(body)},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		res, err := stream.CloseAndRecv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.AggregatedPayloadSize != 5 {
+			t.Errorf("got size %d", res.AggregatedPayloadSize)
+		}
+
+		logs := logger.grpcLogs()
+		if len(logs) != 1 {
+			t.Fatalf("got %d logs, want 1", len(logs))
+		}
+		if logs[0].Code != codes.OK || len(logs[0].Requests) != 2 || len(logs[0].Responses) != 1 {
+			t.Errorf("got log %s", logs[0])
+		}
+	})
+
+	t.Run("abandoned server stream", func(t *testing.T) {
+		logger.entries = nil
+		streamCtx, streamCancel := context.WithCancel(ctx)
+		stream, err := grpc_health_v1.NewHealthClient(pair.Client).Watch(streamCtx, &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			t.Fatal(err)
+		}
+		if logs := logger.grpcLogs(); len(logs) != 0 {
+			t.Fatalf("got %d logs before the stream ended", len(logs))
+		}
+
+		streamCancel()
+		deadline := time.Now().Add(time.Second)
+		for len(logger.grpcLogs()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		logs := logger.grpcLogs()
+		if len(logs) != 1 {
+			t.Fatalf("got %d logs, want 1", len(logs))
+		}
+		if logs[0].Code != codes.Canceled || len(logs[0].Responses) != 1 {
+			t.Errorf("got log %s", logs[0])
+		}
+	})
+}
+
+func TestGrpcPairServerLogger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := &captureLogger{}
+	pair := NewGRPCPairWithOptions(t, WithGRPCServerLogger(logger))
+
+	grpc_testing.RegisterTestServiceServer(pair.Server, streamingInputServer{})
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(pair.Server, healthServer)
+	pair.ServeUntilDone(t, ctx)
+
+	if _, err := grpc_health_v1.NewHealthClient(pair.Client).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := grpc_testing.NewTestServiceClient(pair.Client).StreamingInputCall(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&grpc_testing.StreamingInputCallRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := logger.grpcLogs()
+	if len(logs) != 2 {
+		t.Fatalf("got %d logs, want 2", len(logs))
+	}
+	if logs[0].Method != "/grpc.health.v1.Health/Check" || logs[0].Code != codes.OK || len(logs[0].Responses) != 1 {
+		t.Errorf("got log %s", logs[0])
+	}
+	if !logs[1].Stream || len(logs[1].Requests) != 1 || len(logs[1].Responses) != 1 {
+		t.Errorf("got log %s", logs[1])
+	}
+}
@@ -0,0 +1,306 @@
+package flowtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// GRPCLog is a record of a single gRPC call made by a client, logged into the
+// running step by the logging interceptors.
+type GRPCLog struct {
+	Method          string
+	Stream          bool
+	RequestMetadata metadata.MD
+	Requests        []json.RawMessage
+	ResponseHeader  metadata.MD
+	ResponseTrailer metadata.MD
+	Responses       []json.RawMessage
+	Code            codes.Code
+	Message         string
+}
+
+func (gl *GRPCLog) String() string {
+	lines := []string{fmt.Sprintf("gRPC %s", gl.Method)}
+	lines = appendMetadata(lines, "  > ", gl.RequestMetadata)
+	for _, req := range gl.Requests {
+		lines = append(lines, "  > "+indentJSON(req, "  > "))
+	}
+	lines = append(lines, fmt.Sprintf("  %s: %s", gl.Code, gl.Message))
+	lines = appendMetadata(lines, "  < ", gl.ResponseHeader)
+	for _, res := range gl.Responses {
+		lines = append(lines, "  < "+indentJSON(res, "  < "))
+	}
+	lines = appendMetadata(lines, "  < ", gl.ResponseTrailer)
+	return strings.Join(lines, "\n")
+}
+
+func appendMetadata(lines []string, prefix string, md metadata.MD) []string {
+	for key, vals := range md {
+		for _, val := range vals {
+			lines = append(lines, fmt.Sprintf("%s%s: %s", prefix, key, val))
+		}
+	}
+	return lines
+}
+
+func indentJSON(raw json.RawMessage, prefix string) string {
+	buf := &bytes.Buffer{}
+	if err := json.Indent(buf, raw, prefix, "  "); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+func (gl *GRPCLog) setStatus(err error) {
+	st := status.Convert(err)
+	gl.Code = st.Code()
+	gl.Message = st.Message()
+}
+
+func marshalLogMessage(msg any) json.RawMessage {
+	protoMsg, ok := msg.(proto.Message)
+	if !ok {
+		return json.RawMessage(fmt.Sprintf("%q", fmt.Sprintf("(%T)", msg)))
+	}
+	bb, err := protojson.Marshal(protoMsg)
+	if err != nil {
+		return json.RawMessage(fmt.Sprintf("%q", err.Error()))
+	}
+	return bb
+}
+
+func logContext(ctx context.Context, logger Logger, args ...any) {
+	if contextLogger, ok := logger.(ContextLogger); ok {
+		contextLogger.LogContext(ctx, args...)
+		return
+	}
+	logger.Log(args...)
+}
+
+// WithGRPCLogger logs every call made by the client of a GRPCPair, as a
+// *GRPCLog, into the given logger. Pass the ShiftingLogger of a Stepper to
+// capture the calls into the running step. When the logger is a ContextLogger,
+// the context of the call is used to find the step.
+func WithGRPCLogger(logger Logger) GRPCPairOption {
+	return func(c *grpcPairConfig) {
+		c.unaryClient = append(c.unaryClient, LogUnaryClientInterceptor(logger))
+		c.streamClient = append(c.streamClient, LogStreamClientInterceptor(logger))
+	}
+}
+
+// LogUnaryClientInterceptor logs each unary call as a *GRPCLog.
+func LogUnaryClientInterceptor(logger Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		requestMD, _ := metadata.FromOutgoingContext(ctx)
+		entry := &GRPCLog{
+			Method:          method,
+			RequestMetadata: requestMD,
+			Requests:        []json.RawMessage{marshalLogMessage(req)},
+		}
+		opts = append(opts, grpc.Header(&entry.ResponseHeader), grpc.Trailer(&entry.ResponseTrailer))
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			entry.Responses = append(entry.Responses, marshalLogMessage(reply))
+		}
+		entry.setStatus(err)
+		logContext(ctx, logger, entry)
+		return err
+	}
+}
+
+// LogStreamClientInterceptor logs each streaming call as a *GRPCLog, once the
+// stream has completed, or once the context of the call is done for a stream
+// which is not read to the end.
+func LogStreamClientInterceptor(logger Logger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		requestMD, _ := metadata.FromOutgoingContext(ctx)
+		entry := &GRPCLog{
+			Method:          method,
+			Stream:          true,
+			RequestMetadata: requestMD,
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			entry.setStatus(err)
+			logContext(ctx, logger, entry)
+			return nil, err
+		}
+		logging := &loggingClientStream{
+			ClientStream: stream,
+			ctx:          ctx,
+			desc:         desc,
+			entry:        entry,
+			logger:       logger,
+			done:         make(chan struct{}),
+		}
+		go logging.watch()
+		return logging, nil
+	}
+}
+
+type loggingClientStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	desc   *grpc.StreamDesc
+	entry  *GRPCLog
+	logger Logger
+
+	// lock guards entry, which is not changed once logged.
+	lock   sync.Mutex
+	logged bool
+	done   chan struct{}
+}
+
+func (s *loggingClientStream) SendMsg(m any) error {
+	s.lock.Lock()
+	if !s.logged {
+		s.entry.Requests = append(s.entry.Requests, marshalLogMessage(m))
+	}
+	s.lock.Unlock()
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *loggingClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.lock.Lock()
+		if !s.logged {
+			s.entry.Responses = append(s.entry.Responses, marshalLogMessage(m))
+		}
+		s.lock.Unlock()
+		if !s.desc.ServerStreams {
+			// The single response completes the call, e.g. for CloseAndRecv,
+			// and RecvMsg will not be called again.
+			s.finish(nil, true)
+		}
+		return nil
+	}
+	if errors.Is(err, io.EOF) {
+		s.finish(nil, true)
+	} else {
+		s.finish(err, true)
+	}
+	return err
+}
+
+// watch logs the call when its context is done before the stream completes.
+func (s *loggingClientStream) watch() {
+	select {
+	case <-s.ctx.Done():
+		s.finish(status.FromContextError(s.ctx.Err()).Err(), false)
+	case <-s.done:
+	}
+}
+
+// finish logs the call, once. The trailer can only be read once the stream has
+// ended.
+func (s *loggingClientStream) finish(err error, ended bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.logged {
+		return
+	}
+	s.logged = true
+	close(s.done)
+
+	s.entry.setStatus(err)
+	s.entry.ResponseHeader, _ = s.ClientStream.Header()
+	if ended {
+		s.entry.ResponseTrailer = s.ClientStream.Trailer()
+	}
+	logContext(s.ctx, s.logger, s.entry)
+}
+
+// WithGRPCServerLogger logs every call handled by the server of a GRPCPair, as
+// a *GRPCLog, into the given logger. Calls made through the client of the pair
+// are already logged by WithGRPCLogger, so this is for calls from elsewhere,
+// e.g. from the service under test to a FakeService. The context of a server
+// call does not carry the variation, so with parallel variations the calls are
+// logged into the last step to start.
+func WithGRPCServerLogger(logger Logger) GRPCPairOption {
+	return func(c *grpcPairConfig) {
+		c.unaryServer = append(c.unaryServer, LogUnaryServerInterceptor(logger))
+		c.streamServer = append(c.streamServer, LogStreamServerInterceptor(logger))
+	}
+}
+
+// LogUnaryServerInterceptor logs each unary call handled by the server as a
+// *GRPCLog.
+func LogUnaryServerInterceptor(logger Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestMD, _ := metadata.FromIncomingContext(ctx)
+		entry := &GRPCLog{
+			Method:          info.FullMethod,
+			RequestMetadata: requestMD,
+			Requests:        []json.RawMessage{marshalLogMessage(req)},
+		}
+		res, err := handler(ctx, req)
+		if err == nil {
+			entry.Responses = append(entry.Responses, marshalLogMessage(res))
+		}
+		entry.setStatus(err)
+		logContext(ctx, logger, entry)
+		return res, err
+	}
+}
+
+// LogStreamServerInterceptor logs each streaming call handled by the server as
+// a *GRPCLog, once the handler returns.
+func LogStreamServerInterceptor(logger Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		requestMD, _ := metadata.FromIncomingContext(ss.Context())
+		stream := &loggingServerStream{
+			ServerStream: ss,
+			entry: &GRPCLog{
+				Method:          info.FullMethod,
+				Stream:          true,
+				RequestMetadata: requestMD,
+			},
+		}
+		err := handler(srv, stream)
+		stream.lock.Lock()
+		defer stream.lock.Unlock()
+		stream.entry.setStatus(err)
+		logContext(ss.Context(), logger, stream.entry)
+		return err
+	}
+}
+
+type loggingServerStream struct {
+	grpc.ServerStream
+	entry *GRPCLog
+	lock  sync.Mutex
+}
+
+func (s *loggingServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.lock.Lock()
+		s.entry.Requests = append(s.entry.Requests, marshalLogMessage(m))
+		s.lock.Unlock()
+	}
+	return err
+}
+
+func (s *loggingServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.lock.Lock()
+		s.entry.Responses = append(s.entry.Responses, marshalLogMessage(m))
+		s.lock.Unlock()
+	}
+	return err
+}
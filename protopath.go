@@ -0,0 +1,424 @@
+package flowtest
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoAssertion asserts on the fields of a proto message, addressed by a
+// path of proto field names separated by dots. Repeated fields are indexed
+// with [n], and map fields with [key], e.g. `order.items[0].sku` or
+// `order.labels["env"]`.
+type ProtoAssertion interface {
+	// Has asserts that the field at path is set. Repeated and map fields are
+	// set when they are not empty.
+	Has(path string)
+
+	// NotHas asserts that the field at path is not set, or that a message or
+	// index along the path is not set.
+	NotHas(path string)
+
+	// Equal asserts that the field at path equals want. Enum fields accept
+	// the value name as a string, and numbers are converted to the type of
+	// the field. Unset scalar fields have their default value.
+	Equal(path string, want any, opts ...EqualOption)
+
+	// Oneof asserts that the oneof named by the last element of path has the
+	// field named want set, or that nothing is set when want is empty.
+	Oneof(path string, want string)
+
+	// Enum asserts that the enum field at path has the value named want.
+	Enum(path string, want string)
+
+	// Len asserts that the repeated or map field at path has n entries.
+	Len(path string, n int)
+}
+
+func (a *assertion) Proto(msg proto.Message) ProtoAssertion {
+	return &protoAssertion{
+		assertion: a,
+		msg:       msg.ProtoReflect(),
+	}
+}
+
+type protoAssertion struct {
+	assertion *assertion
+	msg       protoreflect.Message
+}
+
+// protoField is the result of resolving a path.
+type protoField struct {
+	// parent is the message holding the field, nil when a message or index
+	// along the path is not set.
+	parent     protoreflect.Message
+	parentDesc protoreflect.MessageDescriptor
+
+	// field is nil when the path names a oneof.
+	field protoreflect.FieldDescriptor
+	oneof protoreflect.OneofDescriptor
+
+	// element is set when the path ends with an index into field, with the
+	// descriptor of the element.
+	element protoreflect.FieldDescriptor
+
+	value protoreflect.Value
+	set   bool
+}
+
+// reachable is true when every message and index along the path is set.
+func (pf *protoField) reachable() bool {
+	return pf.parent != nil && (pf.element == nil || pf.set)
+}
+
+// valueDesc describes the value at the path, the element when indexed.
+func (pf *protoField) valueDesc() protoreflect.FieldDescriptor {
+	if pf.element != nil {
+		return pf.element
+	}
+	return pf.field
+}
+
+// splitProtoPath splits the path on dots, other than within brackets.
+func splitProtoPath(path string) []string {
+	segments := []string{}
+	depth := 0
+	start := 0
+	for idx, r := range path {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				segments = append(segments, path[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+	return append(segments, path[start:])
+}
+
+func parseMapKey(fd protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+	switch fd.MapKey().Kind() {
+	case protoreflect.StringKind:
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			key = unquoted
+		}
+		return protoreflect.ValueOfString(key).MapKey(), nil
+	case protoreflect.BoolKind:
+		val, err := strconv.ParseBool(key)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		return protoreflect.ValueOfBool(val).MapKey(), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		val, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		return protoreflect.ValueOfInt32(int32(val)).MapKey(), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		val, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		return protoreflect.ValueOfInt64(val).MapKey(), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		val, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		return protoreflect.ValueOfUint32(uint32(val)).MapKey(), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		val, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, err
+		}
+		return protoreflect.ValueOfUint64(val).MapKey(), nil
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("unsupported map key kind %s", fd.MapKey().Kind())
+	}
+}
+
+// resolve walks the path through the message. Messages and indexes which are
+// not set are walked by descriptor only, so that invalid paths are always an
+// error.
+func (pa *protoAssertion) resolve(path string) (*protoField, error) {
+	msg := pa.msg
+	desc := msg.Descriptor()
+	segments := splitProtoPath(path)
+
+	for idx, segment := range segments {
+		last := idx == len(segments)-1
+		name, key, indexed := strings.Cut(segment, "[")
+		if indexed {
+			if !strings.HasSuffix(key, "]") {
+				return nil, fmt.Errorf("invalid index in %q", segment)
+			}
+			key = strings.TrimSuffix(key, "]")
+		}
+
+		pf := &protoField{
+			parent:     msg,
+			parentDesc: desc,
+		}
+
+		pf.field = desc.Fields().ByName(protoreflect.Name(name))
+		if pf.field == nil {
+			pf.field = desc.Fields().ByJSONName(name)
+		}
+		if pf.field == nil {
+			if oneof := desc.Oneofs().ByName(protoreflect.Name(name)); oneof != nil && last && !indexed {
+				pf.oneof = oneof
+				return pf, nil
+			}
+			return nil, fmt.Errorf("%s has no field %q", desc.FullName(), name)
+		}
+
+		if msg != nil {
+			pf.value = msg.Get(pf.field)
+			pf.set = msg.Has(pf.field)
+		}
+
+		if indexed {
+			switch {
+			case pf.field.IsList():
+				listIdx, err := strconv.Atoi(key)
+				if err != nil {
+					return nil, fmt.Errorf("invalid list index in %q", segment)
+				}
+				pf.element = pf.field
+				if msg != nil && listIdx >= 0 && listIdx < pf.value.List().Len() {
+					pf.value = pf.value.List().Get(listIdx)
+				} else {
+					pf.set = false
+				}
+			case pf.field.IsMap():
+				mapKey, err := parseMapKey(pf.field, key)
+				if err != nil {
+					return nil, fmt.Errorf("invalid map key in %q: %w", segment, err)
+				}
+				pf.element = pf.field.MapValue()
+				if msg != nil && pf.value.Map().Has(mapKey) {
+					pf.value = pf.value.Map().Get(mapKey)
+				} else {
+					pf.set = false
+				}
+			default:
+				return nil, fmt.Errorf("%q is not a repeated or map field", name)
+			}
+		}
+
+		if last {
+			return pf, nil
+		}
+
+		valueDesc := pf.valueDesc()
+		if !indexed && (pf.field.IsList() || pf.field.IsMap()) {
+			return nil, fmt.Errorf("%q is repeated, index it to walk into an element", name)
+		}
+		if valueDesc.Message() == nil {
+			return nil, fmt.Errorf("%q is not a message", name)
+		}
+		desc = valueDesc.Message()
+		if pf.set {
+			msg = pf.value.Message()
+		} else {
+			msg = nil
+		}
+	}
+	return nil, fmt.Errorf("empty path")
+}
+
+func (pa *protoAssertion) mustResolve(path string) (*protoField, bool) {
+	pa.assertion.helper()
+	pf, err := pa.resolve(path)
+	if err != nil {
+		pa.assertion.fail("proto path %q: %s", path, err)
+		return nil, false
+	}
+	return pf, true
+}
+
+func (pa *protoAssertion) mustField(path string) (*protoField, bool) {
+	pa.assertion.helper()
+	pf, ok := pa.mustResolve(path)
+	if !ok {
+		return nil, false
+	}
+	if pf.field == nil {
+		pa.assertion.fail("proto path %q: names a oneof, not a field", path)
+		return nil, false
+	}
+	return pf, true
+}
+
+func (pa *protoAssertion) Has(path string) {
+	pa.assertion.helper()
+	pf, ok := pa.mustField(path)
+	if !ok {
+		return
+	}
+	if !pf.set {
+		pa.assertion.fail("proto path %q: not set", path)
+	}
+}
+
+func (pa *protoAssertion) NotHas(path string) {
+	pa.assertion.helper()
+	pf, ok := pa.mustField(path)
+	if !ok {
+		return
+	}
+	if pf.set {
+		pa.assertion.fail("proto path %q: set to %v", path, pf.value)
+	}
+}
+
+func (pa *protoAssertion) Equal(path string, want any, opts ...EqualOption) {
+	pa.assertion.helper()
+	pf, ok := pa.mustField(path)
+	if !ok {
+		return
+	}
+	fd := pf.valueDesc()
+	if pf.element == nil && (fd.IsList() || fd.IsMap()) {
+		pa.assertion.fail("proto path %q: is repeated, use Len or index an element", path)
+		return
+	}
+
+	if !pf.reachable() {
+		pa.assertion.fail("proto path %q: not set, want %v", path, want)
+		return
+	}
+
+	if fd.Message() != nil {
+		if want == nil {
+			if pf.set {
+				pa.assertion.fail("proto path %q: set, want nil", path)
+			}
+			return
+		}
+		if !pf.set {
+			pa.assertion.fail("proto path %q: not set, want %v", path, want)
+			return
+		}
+		if diff := equalDiff(want, pf.value.Message().Interface(), opts); diff != "" {
+			pa.assertion.fail("proto path %q: not equal (-want +got):\n%s", path, diff)
+		}
+		return
+	}
+
+	if fd.Enum() != nil {
+		if wantName, ok := want.(string); ok {
+			pa.Enum(path, wantName)
+			return
+		}
+		if wantEnum, ok := want.(protoreflect.Enum); ok {
+			want = wantEnum.Number()
+		}
+	}
+
+	got := pf.value.Interface()
+	want = convertNumber(want, got)
+	if diff := equalDiff(want, got, opts); diff != "" {
+		pa.assertion.fail("proto path %q: not equal (-want +got):\n%s", path, diff)
+	}
+}
+
+// convertNumber converts want to the type of got when both are numbers, and
+// the conversion can not lose precision, i.e. integers to integers and any
+// number to a float.
+func convertNumber(want, got any) any {
+	wantVal := reflect.ValueOf(want)
+	gotVal := reflect.ValueOf(got)
+	if !wantVal.IsValid() || !gotVal.IsValid() || wantVal.Type() == gotVal.Type() {
+		return want
+	}
+	isInt := func(kind reflect.Kind) bool {
+		return kind >= reflect.Int && kind <= reflect.Uint64
+	}
+	isFloat := func(kind reflect.Kind) bool {
+		return kind == reflect.Float32 || kind == reflect.Float64
+	}
+	wantKind, gotKind := wantVal.Kind(), gotVal.Kind()
+	if (isInt(wantKind) && isInt(gotKind)) || ((isInt(wantKind) || isFloat(wantKind)) && isFloat(gotKind)) {
+		return wantVal.Convert(gotVal.Type()).Interface()
+	}
+	return want
+}
+
+func (pa *protoAssertion) Oneof(path string, want string) {
+	pa.assertion.helper()
+	pf, ok := pa.mustResolve(path)
+	if !ok {
+		return
+	}
+	if pf.oneof == nil {
+		pa.assertion.fail("proto path %q: %s has no oneof %q", path, pf.parentDesc.FullName(), pf.field.Name())
+		return
+	}
+	got := ""
+	if pf.parent != nil {
+		if which := pf.parent.WhichOneof(pf.oneof); which != nil {
+			got = string(which.Name())
+		}
+	}
+	if got != want {
+		pa.assertion.fail("proto path %q: oneof is %q, want %q", path, got, want)
+	}
+}
+
+func (pa *protoAssertion) Enum(path string, want string) {
+	pa.assertion.helper()
+	pf, ok := pa.mustField(path)
+	if !ok {
+		return
+	}
+	enum := pf.valueDesc().Enum()
+	if enum == nil || (pf.element == nil && pf.field.IsList()) {
+		pa.assertion.fail("proto path %q: is not an enum field", path)
+		return
+	}
+	if !pf.reachable() {
+		pa.assertion.fail("proto path %q: not set, want %s", path, want)
+		return
+	}
+	number := pf.value.Enum()
+	got := strconv.Itoa(int(number))
+	if value := enum.Values().ByNumber(number); value != nil {
+		got = string(value.Name())
+	}
+	if got != want {
+		pa.assertion.fail("proto path %q: enum is %s, want %s", path, got, want)
+	}
+}
+
+func (pa *protoAssertion) Len(path string, n int) {
+	pa.assertion.helper()
+	pf, ok := pa.mustField(path)
+	if !ok {
+		return
+	}
+	if pf.element != nil || !(pf.field.IsList() || pf.field.IsMap()) {
+		pa.assertion.fail("proto path %q: is not a repeated or map field", path)
+		return
+	}
+	got := 0
+	if pf.parent != nil {
+		if pf.field.IsList() {
+			got = pf.value.List().Len()
+		} else {
+			got = pf.value.Map().Len()
+		}
+	}
+	if got != n {
+		pa.assertion.fail("proto path %q: has %d entries, want %d", path, got, n)
+	}
+}
@@ -0,0 +1,137 @@
+package flowtest
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProtoAssertion(t *testing.T) {
+	file := &descriptorpb.FileDescriptorProto{
+		Name: proto.String("order.proto"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Order"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("sku"),
+				Number: proto.Int32(1),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}},
+	}
+	labels, err := structpb.NewStruct(map[string]any{"env": "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		msg     proto.Message
+		assert  func(ProtoAssertion)
+		failure string
+	}{{
+		name: "has",
+		msg:  file,
+		assert: func(pa ProtoAssertion) {
+			pa.Has("name")
+			pa.Has("message_type[0].field[0].name")
+			pa.Has("messageType[0].name")
+		},
+	}, {
+		name: "not has",
+		msg:  file,
+		assert: func(pa ProtoAssertion) {
+			pa.NotHas("package")
+			pa.NotHas("message_type[1].name")
+			pa.NotHas("options.java_package")
+		},
+	}, {
+		name: "equal",
+		msg:  file,
+		assert: func(pa ProtoAssertion) {
+			pa.Equal("name", "order.proto")
+			pa.Equal("message_type[0].field[0].number", 1)
+			pa.Equal("message_type[0].field[0].type", "TYPE_STRING")
+			pa.Equal("message_type[0].field[0].type", descriptorpb.FieldDescriptorProto_TYPE_STRING)
+			pa.Equal("message_type[0].field[0].json_name", "")
+			pa.Equal("options", nil)
+		},
+	}, {
+		name: "enum and len",
+		msg:  file,
+		assert: func(pa ProtoAssertion) {
+			pa.Enum("message_type[0].field[0].type", "TYPE_STRING")
+			pa.Len("message_type", 1)
+			pa.Len("message_type[0].field", 1)
+			pa.Len("enum_type", 0)
+		},
+	}, {
+		name: "map and oneof",
+		msg:  labels,
+		assert: func(pa ProtoAssertion) {
+			pa.Oneof(`fields["env"].kind`, "string_value")
+			pa.Equal(`fields["env"].string_value`, "dev")
+			pa.Oneof(`fields["missing"].kind`, "")
+			pa.NotHas(`fields["missing"]`)
+			pa.Len("fields", 1)
+		},
+	}, {
+		name:    "has fails",
+		msg:     file,
+		assert:  func(pa ProtoAssertion) { pa.Has("message_type[0].options") },
+		failure: `proto path "message_type[0].options": not set`,
+	}, {
+		name:    "unknown field",
+		msg:     file,
+		assert:  func(pa ProtoAssertion) { pa.Has("message_type[0].nope") },
+		failure: `proto path "message_type[0].nope": google.protobuf.DescriptorProto has no field "nope"`,
+	}, {
+		name:    "equal fails",
+		msg:     file,
+		assert:  func(pa ProtoAssertion) { pa.Equal("message_type[0].field[0].name", "id") },
+		failure: `proto path "message_type[0].field[0].name": not equal`,
+	}, {
+		name:    "equal through unset index",
+		msg:     file,
+		assert:  func(pa ProtoAssertion) { pa.Equal("message_type[2].name", "Order") },
+		failure: `proto path "message_type[2].name": not set`,
+	}, {
+		name:    "enum fails",
+		msg:     file,
+		assert:  func(pa ProtoAssertion) { pa.Enum("message_type[0].field[0].type", "TYPE_INT32") },
+		failure: "enum is TYPE_STRING, want TYPE_INT32",
+	}, {
+		name:    "len fails",
+		msg:     file,
+		assert:  func(pa ProtoAssertion) { pa.Len("message_type[0].field", 2) },
+		failure: "has 1 entries, want 2",
+	}, {
+		name:    "oneof fails",
+		msg:     labels,
+		assert:  func(pa ProtoAssertion) { pa.Oneof(`fields["env"].kind`, "number_value") },
+		failure: `oneof is "string_value", want "number_value"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWrap{}
+			a := &assertion{
+				fatal:  tw.Fatal,
+				helper: tw.Helper,
+			}
+			tc.assert(a.Proto(tc.msg))
+			if tc.failure == "" {
+				if tw.failed {
+					t.Errorf("unexpected failure: %s", tw.message)
+				}
+				return
+			}
+			if !tw.failed {
+				t.Fatalf("did not fail")
+			}
+			if !strings.Contains(tw.message, tc.failure) {
+				t.Errorf("got failure %q, want %q", tw.message, tc.failure)
+			}
+		})
+	}
+}
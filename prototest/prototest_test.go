@@ -189,3 +189,36 @@ func TestParserErrors(t *testing.T) {
 	})
 
 }
+
+func TestAssertValid(t *testing.T) {
+	set := DescriptorsFromSource(t, map[string]string{
+		"test.proto": `
+		syntax = "proto3";
+
+		package test;
+
+		import "buf/validate/validate.proto";
+
+		message Item {
+			string sku = 1 [(buf.validate.field).string.min_len = 1];
+		}
+
+		message Order {
+			repeated Item items = 1;
+		}
+		`,
+	})
+
+	orderDesc := set.MessageByName(t, "test.Order")
+	itemDesc := set.MessageByName(t, "test.Item")
+
+	item := dynamicpb.NewMessage(itemDesc)
+	order := dynamicpb.NewMessage(orderDesc)
+	items := order.Mutable(orderDesc.Fields().ByName("items")).List()
+	items.Append(protoreflect.ValueOfMessage(item))
+
+	AssertInvalid(t, order, "items[0].sku")
+
+	item.Set(itemDesc.Fields().ByName("sku"), protoreflect.ValueOfString("sku-1"))
+	AssertValid(t, order)
+}
@@ -0,0 +1,49 @@
+package prototest
+
+import (
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/pentops/flowtest"
+	"google.golang.org/protobuf/proto"
+)
+
+func violations(t flowtest.TB, msg proto.Message) []*validate.Violation {
+	t.Helper()
+	found, err := flowtest.ProtoViolations(msg)
+	if err != nil {
+		t.Fatalf("validating %s: %s", msg.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return found
+}
+
+// AssertValid asserts that msg passes its protovalidate rules, e.g. for
+// fixtures which are expected to be accepted by a service.
+func AssertValid(t flowtest.TB, msg proto.Message) {
+	t.Helper()
+	found := violations(t, msg)
+	if len(found) > 0 {
+		t.Errorf("%s is not valid:\n%s", msg.ProtoReflect().Descriptor().FullName(), flowtest.FormatViolations(found))
+	}
+}
+
+// AssertInvalid asserts that msg fails its protovalidate rules, with a
+// violation for each of the field paths.
+func AssertInvalid(t flowtest.TB, msg proto.Message, fieldPaths ...string) {
+	t.Helper()
+	found := violations(t, msg)
+	if len(found) == 0 {
+		t.Errorf("%s is valid, want violations", msg.ProtoReflect().Descriptor().FullName())
+		return
+	}
+	for _, path := range fieldPaths {
+		matched := false
+		for _, violation := range found {
+			if violation.FieldPath == path {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			t.Errorf("%s has no violation for %q, got:\n%s", msg.ProtoReflect().Descriptor().FullName(), path, flowtest.FormatViolations(found))
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks that every dependency names a registered test, that the
+// names of tests which are depended on are unique, and that there are no
+// dependency cycles. Other tests may share a name, as allowed by Register.
+func (ts TestSet) Validate() error {
+	count := map[string]int{}
+	for _, test := range ts {
+		count[test.Name]++
+	}
+
+	for _, test := range ts {
+		for _, dep := range test.DependsOn {
+			switch count[dep] {
+			case 0:
+				return fmt.Errorf("test %q depends on unknown test %q", test.Name, dep)
+			case 1:
+			default:
+				return fmt.Errorf("test %q depends on %q, which is registered %d times", test.Name, dep, count[dep])
+			}
+		}
+	}
+
+	return ts.checkCycles()
+}
+
+// checkCycles returns an error for the first dependency cycle found. Unknown
+// dependencies are ignored, as they may be registered later.
+func (ts TestSet) checkCycles() error {
+	deps := map[string][]string{}
+	for _, test := range ts {
+		deps[test.Name] = test.DependsOn
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	for _, test := range ts {
+		if err := visit(test.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schedule tracks which tests are ready to run, in Order, once all of their
+// dependencies have passed.
+type schedule struct {
+	tests   TestSet
+	reports []*TestReport
+	started []bool
+	status  map[string]Status
+}
+
+// newSchedule builds a schedule for tests, which must already be sorted.
+// Dependencies on tests which are not in the schedule, e.g. excluded by a
+// filter, are treated as satisfied.
+func newSchedule(tests TestSet, reports []*TestReport) *schedule {
+	sched := &schedule{
+		tests:   tests,
+		reports: reports,
+		started: make([]bool, len(tests)),
+		status:  map[string]Status{},
+	}
+	for _, test := range tests {
+		sched.status[test.Name] = ""
+	}
+	return sched
+}
+
+// next returns the index of the first test in Order which has not started
+// and has all dependencies passed.
+func (s *schedule) next() (int, bool) {
+	for idx, test := range s.tests {
+		if s.started[idx] || s.reports[idx].Status != "" {
+			continue
+		}
+		if s.ready(test) {
+			s.started[idx] = true
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+func (s *schedule) ready(test Test) bool {
+	for _, dep := range test.DependsOn {
+		status, ok := s.status[dep]
+		if !ok {
+			continue
+		}
+		if status != StatusPassed {
+			return false
+		}
+	}
+	return true
+}
+
+// complete records the result of a test. When the test did not pass, every
+// test depending on it, directly or through other tests, is skipped.
+func (s *schedule) complete(idx int) {
+	test := s.tests[idx]
+	status := s.reports[idx].Status
+	s.status[test.Name] = status
+	if status == StatusPassed {
+		return
+	}
+	for depIdx, dependent := range s.tests {
+		if s.started[depIdx] || s.reports[depIdx].Status != "" {
+			continue
+		}
+		for _, dep := range dependent.DependsOn {
+			if dep == test.Name {
+				s.reports[depIdx].Status = StatusSkipped
+				s.reports[depIdx].SkipReason = fmt.Sprintf("dependency %q %s", test.Name, status)
+				s.complete(depIdx)
+				break
+			}
+		}
+	}
+}
+
+// skipRemaining skips every test which has not yet started.
+func (s *schedule) skipRemaining(reason string) {
+	for idx := range s.tests {
+		if s.started[idx] || s.reports[idx].Status != "" {
+			continue
+		}
+		s.reports[idx].Status = StatusSkipped
+		s.reports[idx].SkipReason = reason
+		s.status[s.tests[idx].Name] = StatusSkipped
+	}
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pentops/flowtest"
+)
+
+func TestRegisterCycle(t *testing.T) {
+	ts := TestSet{}
+	ts.RegisterTest(Test{Name: "a", DependsOn: []string{"b"}})
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expected a panic for the cycle")
+		}
+		if !strings.Contains(r.(string), "a -> b -> a") && !strings.Contains(r.(string), "b -> a -> b") {
+			t.Errorf("unexpected panic: %v", r)
+		}
+	}()
+	ts.RegisterTest(Test{Name: "b", DependsOn: []string{"a"}})
+}
+
+func TestValidateUnknown(t *testing.T) {
+	ts := TestSet{}
+	ts.RegisterTest(Test{Name: "a", DependsOn: []string{"missing"}})
+	err := ts.Validate()
+	if err == nil || !strings.Contains(err.Error(), `unknown test "missing"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateDuplicate(t *testing.T) {
+	ran := 0
+	ts := TestSet{}
+	for i := 0; i < 2; i++ {
+		ts.Register(1, "same", func(ss flowtest.StepSetter) {
+			ss.Step("step", func(ctx context.Context, a flowtest.Asserter) {
+				ran++
+			})
+		})
+	}
+	if err := ts.Run(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if ran != 2 {
+		t.Errorf("ran %d tests with the same name, want 2", ran)
+	}
+
+	ts.RegisterTest(Test{Name: "dependent", DependsOn: []string{"same"}})
+	err := ts.Validate()
+	if err == nil || !strings.Contains(err.Error(), `registered 2 times`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDependencyFailure(t *testing.T) {
+	ran := []string{}
+	test := func(name string, order float64, fail bool, deps ...string) Test {
+		return Test{
+			Name:      name,
+			Order:     order,
+			DependsOn: deps,
+			Setup: func(ss flowtest.StepSetter) {
+				ss.Step(name, func(ctx context.Context, a flowtest.Asserter) {
+					ran = append(ran, name)
+					if fail {
+						a.Fatal("failing")
+					}
+				})
+			},
+		}
+	}
+
+	ts := TestSet{}
+	// Order would run the dependent first without the dependency
+	ts.RegisterTest(test("dependent", 1, false, "fails"))
+	ts.RegisterTest(test("transitive", 2, false, "dependent"))
+	ts.RegisterTest(test("fails", 3, true))
+	ts.RegisterTest(test("independent", 4, false))
+
+	t.Run("keep going", func(t *testing.T) {
+		ran = nil
+		report, err := ts.RunWithOptions(context.Background(), RunOptions{KeepGoing: true})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if strings.Join(ran, ",") != "fails,independent" {
+			t.Errorf("unexpected tests ran: %v", ran)
+		}
+		for _, testReport := range report.Tests {
+			if testReport.Name == "transitive" {
+				if testReport.Status != StatusSkipped || testReport.SkipReason != `dependency "dependent" skipped` {
+					t.Errorf("unexpected report for transitive: %+v", testReport)
+				}
+			}
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		ran = nil
+		report, err := ts.RunWithOptions(context.Background(), RunOptions{})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if strings.Join(ran, ",") != "fails" {
+			t.Errorf("unexpected tests ran: %v", ran)
+		}
+		counts := report.Counts()
+		if counts[StatusSkipped] != 3 {
+			t.Errorf("unexpected counts %v", counts)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Main runs the DefaultTestSet as a command line program, configured by flags,
+// and exits with a non-zero status if any test fails.
+func Main() {
+	os.Exit(DefaultTestSet.Main(context.Background(), os.Args[0], os.Args[1:]))
+}
+
+type tagFlags []string
+
+func (tf *tagFlags) String() string {
+	return strings.Join(*tf, ",")
+}
+
+func (tf *tagFlags) Set(val string) error {
+	*tf = append(*tf, val)
+	return nil
+}
+
+type cliConfig struct {
+	RunOptions
+	list      bool
+	junitPath string
+	jsonPath  string
+	timeout   time.Duration
+}
+
+func parseFlags(name string, args []string, output io.Writer) (*cliConfig, error) {
+	config := &cliConfig{}
+	tags := tagFlags{}
+	var namePattern string
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Var(&tags, "tag", "run only tests with the tag, or with key=value for category tags. Repeatable.")
+	fs.StringVar(&namePattern, "run", "", "run only tests with names matching the regular expression")
+	fs.BoolVar(&config.list, "list", false, "list the selected tests without running them")
+	fs.StringVar(&config.junitPath, "junit", "", "write a JUnit XML report to the path")
+	fs.StringVar(&config.jsonPath, "json", "", "write a JSON report to the path")
+	fs.BoolVar(&config.KeepGoing, "keep-going", false, "run the remaining tests after a failure, rather than stopping")
+	fs.BoolVar(&config.Quiet, "quiet", false, "print the output of failed steps only")
+	fs.IntVar(&config.Concurrency, "concurrency", 1, "the number of tests to run at the same time")
+	fs.DurationVar(&config.timeout, "timeout", 0, "fail the run if it does not complete within the duration")
+	fs.DurationVar(&config.TestTimeout, "test-timeout", 0, "fail each test which does not complete within the duration")
+	fs.DurationVar(&config.StepTimeout, "step-timeout", 0, "fail each step which does not complete within the duration")
+	fs.DurationVar(&config.HookTimeout, "hook-timeout", 0, "fail each setup, background or teardown hook which does not complete within the duration")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	config.Filter = tags
+	if namePattern != "" {
+		re, err := regexp.Compile(namePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -run pattern: %w", err)
+		}
+		config.NamePattern = re
+	}
+	return config, nil
+}
+
+// Main runs the test set as a command line program with the given arguments,
+// returning the exit code.
+func (ts *TestSet) Main(ctx context.Context, name string, args []string) int {
+	config, err := parseFlags(name, args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if err := ts.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if config.list {
+		for _, test := range ts.Selected(config.RunOptions) {
+			fmt.Println(test.Name)
+		}
+		return 0
+	}
+
+	if config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+		defer cancel()
+	}
+
+	report, runErr := ts.RunWithOptions(ctx, config.RunOptions)
+
+	exitCode := 0
+	if runErr != nil {
+		exitCode = 1
+	}
+
+	if config.junitPath != "" {
+		if err := writeReport(config.junitPath, report.WriteJUnit); err != nil {
+			fmt.Fprintf(os.Stderr, "writing JUnit report: %s\n", err)
+			exitCode = 1
+		}
+	}
+	if config.jsonPath != "" {
+		if err := writeReport(config.jsonPath, report.WriteJSON); err != nil {
+			fmt.Fprintf(os.Stderr, "writing JSON report: %s\n", err)
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+func writeReport(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
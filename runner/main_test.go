@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseFlags(t *testing.T) {
+	config, err := parseFlags("test", []string{
+		"-tag", "smoke",
+		"-tag", "env=dev",
+		"-run", "^order",
+		"-keep-going",
+		"-concurrency", "4",
+		"-timeout", "5m",
+		"-step-timeout", "10s",
+		"-junit", "out.xml",
+	}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Filter) != 2 || config.Filter[0] != "smoke" || config.Filter[1] != "env=dev" {
+		t.Errorf("unexpected filter %v", config.Filter)
+	}
+	if config.NamePattern == nil || !config.NamePattern.MatchString("order flow") || config.NamePattern.MatchString("new order") {
+		t.Errorf("unexpected name pattern %v", config.NamePattern)
+	}
+	if !config.KeepGoing || config.Quiet || config.list {
+		t.Errorf("unexpected booleans %+v", config)
+	}
+	if config.Concurrency != 4 {
+		t.Errorf("got concurrency %d", config.Concurrency)
+	}
+	if config.timeout != 5*time.Minute {
+		t.Errorf("got timeout %s", config.timeout)
+	}
+	if config.StepTimeout != 10*time.Second || config.TestTimeout != 0 {
+		t.Errorf("got step timeout %s, test timeout %s", config.StepTimeout, config.TestTimeout)
+	}
+	if config.junitPath != "out.xml" || config.jsonPath != "" {
+		t.Errorf("unexpected report paths %q %q", config.junitPath, config.jsonPath)
+	}
+
+	if _, err := parseFlags("test", []string{"-run", "("}, io.Discard); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,231 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pentops/flowtest/be"
+)
+
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Report is the structured result of running a TestSet.
+type Report struct {
+	Tests    []*TestReport `json:"tests"`
+	Duration float64       `json:"durationSeconds"`
+}
+
+// TestReport is the result of a single registered Test.
+type TestReport struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Duration float64       `json:"durationSeconds"`
+	Steps    []*StepReport `json:"steps,omitempty"`
+
+	// Logs, Failures and Outcomes are recorded outside of any step, e.g. by
+	// Setup hooks.
+	Logs     []string         `json:"logs,omitempty"`
+	Failures []string         `json:"failures,omitempty"`
+	Outcomes []*OutcomeReport `json:"outcomes,omitempty"`
+
+	// SkipReason is set when the test did not run.
+	SkipReason string `json:"skipReason,omitempty"`
+
+	// Attempts is the number of times the test ran, more than one when the
+	// test was retried, with the reports of the failed attempts in
+	// PreviousAttempts.
+	Attempts         int           `json:"attempts,omitempty"`
+	PreviousAttempts []*TestReport `json:"previousAttempts,omitempty"`
+}
+
+// StepReport is the result of a single step within a test.
+type StepReport struct {
+	Name       string   `json:"name"`
+	Variation  *int     `json:"variation,omitempty"`
+	Status     Status   `json:"status"`
+	SkipReason string   `json:"skipReason,omitempty"`
+	Duration   float64  `json:"durationSeconds"`
+	Logs       []string `json:"logs,omitempty"`
+	Failures   []string `json:"failures,omitempty"`
+
+	// Outcomes are the structured failures of be matchers, also included as
+	// text in Failures.
+	Outcomes []*OutcomeReport `json:"outcomes,omitempty"`
+}
+
+// OutcomeReport is a failed be.Outcome, with the values rendered as text so
+// that any value can be reported.
+type OutcomeReport struct {
+	Message string `json:"message"`
+	Want    string `json:"want,omitempty"`
+	Got     string `json:"got,omitempty"`
+	Diff    string `json:"diff,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+func newOutcomeReport(outcome *be.Outcome) *OutcomeReport {
+	report := &OutcomeReport{
+		Message: outcome.Message,
+		Diff:    outcome.Diff,
+		Path:    outcome.Path,
+	}
+	if outcome.Want != nil {
+		report.Want = fmt.Sprintf("%v", outcome.Want)
+	}
+	if outcome.Got != nil {
+		report.Got = fmt.Sprintf("%v", outcome.Got)
+	}
+	return report
+}
+
+// Counts returns the number of tests with each status.
+func (r *Report) Counts() map[Status]int {
+	counts := map[Status]int{}
+	for _, test := range r.Tests {
+		counts[test.Status]++
+	}
+	return counts
+}
+
+// WriteJSON writes the report as an indented JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	TestCases []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+func junitFailure(status Status, failures []string) *junitMessage {
+	if status != StatusFailed {
+		return nil
+	}
+	msg := &junitMessage{
+		Message: "failed",
+		Body:    strings.Join(failures, "\n"),
+	}
+	if len(failures) > 0 {
+		msg.Message, _, _ = strings.Cut(failures[0], "\n")
+	}
+	return msg
+}
+
+// WriteJUnit writes the report as JUnit XML, with a testsuite for each test
+// and a testcase for each step.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suites := junitSuites{
+		Time: junitTime(r.Duration),
+	}
+
+	for _, test := range r.Tests {
+		suite := junitSuite{
+			Name:      test.Name,
+			Time:      junitTime(test.Duration),
+			SystemOut: strings.Join(test.Logs, "\n"),
+		}
+
+		stepFailed := false
+		for _, step := range test.Steps {
+			tc := junitCase{
+				Name:      step.Name,
+				ClassName: test.Name,
+				Time:      junitTime(step.Duration),
+				Failure:   junitFailure(step.Status, step.Failures),
+				SystemOut: strings.Join(step.Logs, "\n"),
+			}
+			switch step.Status {
+			case StatusFailed:
+				stepFailed = true
+				suite.Failures++
+			case StatusSkipped:
+				suite.Skipped++
+				tc.Skipped = &junitMessage{Message: "skipped"}
+				if step.SkipReason != "" {
+					tc.Skipped.Message = step.SkipReason
+				}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		// Failures and skips of the whole test, outside of any step, are
+		// reported as a case named for the test.
+		if test.Status == StatusSkipped && len(test.Steps) == 0 {
+			suite.Skipped++
+			suite.TestCases = append(suite.TestCases, junitCase{
+				Name:      test.Name,
+				ClassName: test.Name,
+				Time:      junitTime(0),
+				Skipped:   &junitMessage{Message: test.SkipReason},
+			})
+		} else if test.Status == StatusFailed && !stepFailed {
+			suite.Failures++
+			suite.TestCases = append(suite.TestCases, junitCase{
+				Name:      test.Name,
+				ClassName: test.Name,
+				Time:      junitTime(test.Duration),
+				Failure:   junitFailure(test.Status, test.Failures),
+			})
+		}
+
+		suite.Tests = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pentops/flowtest"
+	"github.com/pentops/flowtest/be"
+)
+
+func TestRunReport(t *testing.T) {
+	ts := TestSet{}
+	ts.Register(1, "passes", func(ss flowtest.StepSetter) {
+		ss.Step("one", func(ctx context.Context, a flowtest.Asserter) {
+			a.Log("hello")
+		})
+	}, "main")
+	ts.Register(2, "fails", func(ss flowtest.StepSetter) {
+		ss.Variation("only", func(ctx context.Context, a flowtest.Asserter) {})
+		ss.Step("one", func(ctx context.Context, a flowtest.Asserter) {
+			a.Fatal("broken")
+		})
+	}, "main")
+	ts.Register(3, "filtered", func(ss flowtest.StepSetter) {}, "other")
+
+	report, err := ts.RunWithOptions(context.Background(), RunOptions{
+		Filter: []string{"main"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for the failed test")
+	}
+
+	counts := report.Counts()
+	if counts[StatusPassed] != 1 || counts[StatusFailed] != 1 || counts[StatusSkipped] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+
+	passes := report.Tests[0]
+	if len(passes.Steps) != 1 || passes.Steps[0].Logs[0] != "hello" {
+		t.Errorf("log not captured: %#v", passes.Steps)
+	}
+
+	fails := report.Tests[1]
+	if len(fails.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(fails.Steps))
+	}
+	failed := fails.Steps[1]
+	if failed.Status != StatusFailed || failed.Variation == nil || *failed.Variation != 0 {
+		t.Errorf("unexpected step report %#v", failed)
+	}
+	if len(failed.Failures) != 1 || failed.Failures[0] != "broken" {
+		t.Errorf("failure not captured: %v", failed.Failures)
+	}
+
+	jsonOut := &bytes.Buffer{}
+	if err := report.WriteJSON(jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Report{}
+	if err := json.Unmarshal(jsonOut.Bytes(), decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Tests) != 3 {
+		t.Errorf("got %d tests in JSON, want 3", len(decoded.Tests))
+	}
+
+	junitOut := &bytes.Buffer{}
+	if err := report.WriteJUnit(junitOut); err != nil {
+		t.Fatal(err)
+	}
+	junit := junitOut.String()
+	for _, want := range []string{
+		`<testsuites tests="4" failures="1" skipped="1"`,
+		`<failure message="broken">broken</failure>`,
+	} {
+		if !strings.Contains(junit, want) {
+			t.Errorf("JUnit output missing %q:\n%s", want, junit)
+		}
+	}
+}
+
+func TestSkipReport(t *testing.T) {
+	ts := TestSet{}
+	ts.Register(1, "not deployed", func(ss flowtest.StepSetter) {
+		ss.Step("one", func(ctx context.Context, a flowtest.Asserter) {
+			a.Skip("feature not deployed")
+		})
+		ss.Step("two", func(ctx context.Context, a flowtest.Asserter) {
+			a.SkipNow()
+		})
+	})
+	ts.Register(2, "partly skipped", func(ss flowtest.StepSetter) {
+		ss.Step("one", func(ctx context.Context, a flowtest.Asserter) {
+			a.Skip("optional")
+		})
+		ss.Step("two", func(ctx context.Context, a flowtest.Asserter) {})
+	})
+
+	report, err := ts.RunWithOptions(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skipped := report.Tests[0]
+	if skipped.Status != StatusSkipped || skipped.SkipReason != "all steps skipped" {
+		t.Errorf("unexpected skipped test %+v", skipped)
+	}
+	if step := skipped.Steps[0]; step.Status != StatusSkipped || step.SkipReason != "feature not deployed" {
+		t.Errorf("unexpected skipped step %+v", step)
+	}
+
+	partly := report.Tests[1]
+	if partly.Status != StatusPassed {
+		t.Errorf("partly skipped test %s", partly.Status)
+	}
+	if partly.Steps[0].Status != StatusSkipped || partly.Steps[1].Status != StatusPassed {
+		t.Errorf("unexpected steps %s, %s", partly.Steps[0].Status, partly.Steps[1].Status)
+	}
+
+	junit := &bytes.Buffer{}
+	if err := report.WriteJUnit(junit); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(junit.String(), `<skipped message="feature not deployed">`) {
+		t.Errorf("JUnit report missing skip reason:\n%s", junit.String())
+	}
+}
+
+func TestOutcomeReport(t *testing.T) {
+	ts := TestSet{}
+	ts.Register(1, "compares", func(ss flowtest.StepSetter) {
+		ss.Step("equal", func(ctx context.Context, a flowtest.Asserter) {
+			a.T(be.Equal("want", "got").At("order.sku"))
+		})
+	})
+
+	report, err := ts.RunWithOptions(context.Background(), RunOptions{})
+	if err == nil {
+		t.Fatal("expected the test to fail")
+	}
+
+	step := report.Tests[0].Steps[0]
+	if len(step.Outcomes) != 1 {
+		t.Fatalf("unexpected outcomes %+v", step.Outcomes)
+	}
+	outcome := step.Outcomes[0]
+	if outcome.Path != "order.sku" || outcome.Want != "want" || outcome.Got != "got" {
+		t.Errorf("unexpected outcome %+v", outcome)
+	}
+	if len(step.Failures) != 1 || !strings.Contains(step.Failures[0], "order.sku: got got, want want") {
+		t.Errorf("unexpected failures %q", step.Failures)
+	}
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/pentops/flowtest/runner/testclient"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy allows a failed test to be run again, for tests against
+// environments where transient failures are expected.
+type RetryPolicy struct {
+	// Attempts is the maximum number of times the test runs, including the
+	// first run.
+	Attempts int
+
+	// Backoff is the wait before the first retry, doubling for each following
+	// retry.
+	Backoff time.Duration
+
+	// Codes limits retries to failures caused by gRPC status errors with one
+	// of the given codes.
+	Codes []codes.Code
+
+	// HTTPStatuses limits retries to failures caused by testclient.APIError
+	// with one of the given status codes.
+	HTTPStatuses []int
+}
+
+// shouldRetry returns true if the test should run again after failing on the
+// given attempt, counting from 1, with the recorded errors.
+func (rp *RetryPolicy) shouldRetry(attempt int, errs []error) bool {
+	if rp == nil || attempt >= rp.Attempts {
+		return false
+	}
+	if len(rp.Codes) == 0 && len(rp.HTTPStatuses) == 0 {
+		return true
+	}
+	for _, err := range errs {
+		if rp.retryable(err) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rp *RetryPolicy) retryable(err error) bool {
+	if len(rp.HTTPStatuses) > 0 {
+		apiErr := &testclient.APIError{}
+		if errors.As(err, &apiErr) && slices.Contains(rp.HTTPStatuses, apiErr.StatusCode) {
+			return true
+		}
+	}
+	if len(rp.Codes) > 0 {
+		if st, ok := status.FromError(err); ok && slices.Contains(rp.Codes, st.Code()) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before running the given attempt, counting from 1.
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	return rp.Backoff << (attempt - 2)
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pentops/flowtest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetry(t *testing.T) {
+	runs := map[string]int{}
+	failOnce := func(name string, code codes.Code) TestCallback {
+		return func(ss flowtest.StepSetter) {
+			ss.Step("call", func(ctx context.Context, a flowtest.Asserter) {
+				runs[name]++
+				if runs[name] == 1 {
+					a.NoError(status.Error(code, "transient"))
+				}
+			})
+		}
+	}
+
+	policy := &RetryPolicy{
+		Attempts: 3,
+		Codes:    []codes.Code{codes.Unavailable},
+	}
+
+	ts := TestSet{}
+	ts.RegisterTest(Test{Name: "retried", Setup: failOnce("retried", codes.Unavailable), Retry: policy})
+	ts.RegisterTest(Test{Name: "not retried", Setup: failOnce("not retried", codes.NotFound), Retry: policy})
+
+	report, err := ts.RunWithOptions(context.Background(), RunOptions{KeepGoing: true})
+	if err == nil {
+		t.Fatal("expected the non-retryable test to fail")
+	}
+
+	if runs["retried"] != 2 || runs["not retried"] != 1 {
+		t.Errorf("unexpected runs %v", runs)
+	}
+
+	retried := report.Tests[0]
+	if retried.Status != StatusPassed || retried.Attempts != 2 || len(retried.PreviousAttempts) != 1 {
+		t.Errorf("unexpected report %+v", retried)
+	}
+	if retried.PreviousAttempts[0].Status != StatusFailed {
+		t.Errorf("previous attempt should have failed")
+	}
+
+	notRetried := report.Tests[1]
+	if notRetried.Status != StatusFailed || notRetried.Attempts != 1 {
+		t.Errorf("unexpected report %+v", notRetried)
+	}
+}
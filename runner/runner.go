@@ -1,10 +1,15 @@
 package runner
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"slices"
 
@@ -19,6 +24,17 @@ type Test struct {
 	Name  string
 	Setup TestCallback
 
+	// DependsOn names tests which must pass before this test runs. When a
+	// dependency fails or is skipped, this test is skipped. Order is used
+	// between tests which are ready to run at the same time.
+	DependsOn []string
+
+	// Retry, when set, runs the test again after a failure.
+	Retry *RetryPolicy
+
+	// Timeout, when set, overrides RunOptions.TestTimeout for this test.
+	Timeout time.Duration
+
 	CategoryTags map[string][]string
 	Tags         []string
 }
@@ -96,6 +112,23 @@ func (ts *TestSet) Register(order float64, name string, callback TestCallback, t
 	})
 }
 
+// RegisterTest registers a fully specified test into the test set.
+// It panics if the name is already registered, or if the dependencies create a
+// cycle with the tests already registered. Dependencies on tests which are not
+// yet registered are checked by Validate before the tests run.
+func (ts *TestSet) RegisterTest(test Test) {
+	for _, existing := range *ts {
+		if existing.Name == test.Name {
+			panic(fmt.Sprintf("test %q registered twice", test.Name))
+		}
+	}
+	withTest := append(slices.Clone(*ts), test)
+	if err := withTest.checkCycles(); err != nil {
+		panic(fmt.Sprintf("registering test %q: %s", test.Name, err))
+	}
+	*ts = withTest
+}
+
 var DefaultTestSet TestSet
 
 // Register registers a testing callback into the default test set.
@@ -104,61 +137,328 @@ func Register(order float64, name string, callback TestCallback, tags ...string)
 	DefaultTestSet.Register(order, name, callback, tags...)
 }
 
+// RegisterTest registers a fully specified test into the default test set.
+// It is usually called from an init() function.
+func RegisterTest(test Test) {
+	DefaultTestSet.RegisterTest(test)
+}
+
+// RunOptions configures a run of a TestSet.
+type RunOptions struct {
+	// Filter selects tests by tag, see Register.
+	Filter []string
+
+	// NamePattern, when set, selects only tests with a matching name.
+	NamePattern *regexp.Regexp
+
+	// KeepGoing runs the remaining tests after a failure, skipping only the
+	// tests which depend on the failed test. By default every remaining test
+	// is skipped after the first failure.
+	KeepGoing bool
+
+	// Quiet prints the output of failed steps only, the report still captures
+	// all output.
+	Quiet bool
+
+	// Concurrency is the number of tests to run at the same time, as their
+	// dependencies allow. When greater than one, the output of each test is
+	// buffered and printed once the test completes.
+	Concurrency int
+
+	// TestTimeout fails each test which does not complete within the
+	// duration, printing the stacks of all goroutines. A test which still does
+	// not return is abandoned so that the run can continue.
+	TestTimeout time.Duration
+
+	// StepTimeout and HookTimeout limit each step and each Setup, Background
+	// and Teardown hook, see flowtest.Timeouts.
+	StepTimeout time.Duration
+	HookTimeout time.Duration
+}
+
+// abandonGrace is how long a test which has timed out is given to return
+// after its context is cancelled, before the run continues without it.
+var abandonGrace = 5 * time.Second
+
+// exclusionReason returns why the test is not selected by the options, or an
+// empty string when it should run.
+func (t *Test) exclusionReason(opts RunOptions) string {
+	flatFilter, catFilter := splitTags(opts.Filter)
+	if !t.TagsMatch(flatFilter) || !t.CategoriesMatch(catFilter) {
+		return fmt.Sprintf("tags %v do not match filter %v", t.Tags, opts.Filter)
+	}
+	if opts.NamePattern != nil && !opts.NamePattern.MatchString(t.Name) {
+		return fmt.Sprintf("name does not match %q", opts.NamePattern)
+	}
+	return ""
+}
+
+// Selected returns the tests which would be run with the given options, in
+// the order they would run.
+func (ts *TestSet) Selected(opts RunOptions) TestSet {
+	sort.Stable(ts)
+	selected := make(TestSet, 0, len(*ts))
+	for _, test := range *ts {
+		if test.exclusionReason(opts) == "" {
+			selected = append(selected, test)
+		}
+	}
+	return selected
+}
+
 // Run runs the registered tests, filtered per the filter rules.
 func (ts *TestSet) Run(ctx context.Context, filter []string) error {
-	sort.Sort(ts)
+	_, err := ts.RunWithOptions(ctx, RunOptions{
+		Filter: filter,
+	})
+	return err
+}
+
+// RunWithOptions runs the registered tests, returning a report of every test,
+// including those skipped by the filter.
+func (ts *TestSet) RunWithOptions(ctx context.Context, opts RunOptions) (*Report, error) {
+	if err := ts.Validate(); err != nil {
+		return nil, err
+	}
+	sort.Stable(ts)
 
-	flatFilter, catFilter := splitTags(filter)
-	toRun := make(TestSet, 0, len(*ts))
+	report := &Report{}
+	runStart := time.Now()
+
+	toRun := make([]*TestReport, 0, len(*ts))
+	tests := make(TestSet, 0, len(*ts))
 	for _, test := range *ts {
-		if !test.TagsMatch(flatFilter) {
-			fmt.Printf("skipping %s due to tags %v != %v\n", test.Name, filter, test.Tags)
-			continue
+		testReport := &TestReport{
+			Name: test.Name,
 		}
-		if !test.CategoriesMatch(catFilter) {
-			fmt.Printf("skipping %s due to tags %v != %v\n", test.Name, filter, test.Tags)
+		report.Tests = append(report.Tests, testReport)
+		if reason := test.exclusionReason(opts); reason != "" {
+			testReport.Status = StatusSkipped
+			testReport.SkipReason = reason
 			continue
 		}
-		toRun = append(toRun, test)
+		tests = append(tests, test)
+		toRun = append(toRun, testReport)
 	}
 
-	failures := []string{}
+	workers := max(opts.Concurrency, 1)
+	var outputLock sync.Mutex
 
-	red := color.New(color.FgRed).PrintfFunc()
-	green := color.New(color.FgGreen).PrintfFunc()
+	lock := sync.Mutex{}
+	cond := sync.NewCond(&lock)
+	running := 0
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	sched := newSchedule(tests, toRun)
 
-	for _, test := range toRun {
-		test := test
+	lock.Lock()
+	for {
+		for running >= workers {
+			cond.Wait()
+		}
+		idx, ok := sched.next()
+		if !ok {
+			if running == 0 {
+				break
+			}
+			cond.Wait()
+			continue
+		}
+
+		running++
+		go func() {
+			test := tests[idx]
+			testReport := toRun[idx]
 
-		testLabel := fmt.Sprintf("%f: %s", test.Order, test.Name)
-		stepper := flowtest.NewStepper[*TBImpl](testLabel)
+			var out io.Writer = color.Output
+			var buffer *bytes.Buffer
+			if workers > 1 {
+				buffer = &bytes.Buffer{}
+				out = buffer
+			}
 
-		green("== %s == Running\n", testLabel)
-		tb := &TBImpl{
-			context: ctx,
+			result := runWithRetries(ctx, test, opts, out)
+			failed := result.Status == StatusFailed
+
+			if buffer != nil {
+				outputLock.Lock()
+				_, _ = color.Output.Write(buffer.Bytes())
+				outputLock.Unlock()
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			*testReport = *result
+			sched.complete(idx)
+			if failed && !opts.KeepGoing {
+				sched.skipRemaining(fmt.Sprintf("stopped after %q failed", test.Name))
+			}
+			running--
+			cond.Broadcast()
+		}()
+	}
+	lock.Unlock()
+
+	report.Duration = time.Since(runStart).Seconds()
+
+	return report, printSummary(report)
+}
+
+// runWithRetries runs the test until it passes or the retry policy is
+// exhausted, returning the report of the final attempt.
+func runWithRetries(ctx context.Context, test Test, opts RunOptions, out io.Writer) *TestReport {
+	previous := []*TestReport{}
+	for attempt := 1; ; attempt++ {
+		testReport := &TestReport{
+			Name: test.Name,
+		}
+		start := time.Now()
+		failed, errs := runTest(ctx, test, testReport, opts, out)
+		testReport.Duration = time.Since(start).Seconds()
+		testReport.Status = StatusPassed
+		if failed {
+			testReport.Status = StatusFailed
+		} else if testReport.SkipReason != "" {
+			testReport.Status = StatusSkipped
+		}
+
+		if !failed || !test.Retry.shouldRetry(attempt, errs) || ctx.Err() != nil {
+			testReport.Attempts = attempt
+			testReport.PreviousAttempts = previous
+			return testReport
+		}
+		previous = append(previous, testReport)
+
+		backoff := test.Retry.backoff(attempt + 1)
+		color.New(color.FgYellow).Fprintf(out, "== Retrying %s after %s (attempt %d of %d)\n", test.Name, backoff, attempt+1, test.Retry.Attempts)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
 		}
+	}
+}
 
-		test.Setup(stepper)
+// runTest runs a single test, writing its output to out, and returns true if
+// the test failed, with the errors which caused the failure. When the test
+// skipped itself, or every step was skipped, SkipReason is set on testReport.
+func runTest(ctx context.Context, test Test, testReport *TestReport, opts RunOptions, out io.Writer) (bool, []error) {
+	testLabel := fmt.Sprintf("%f: %s", test.Order, test.Name)
+	stepper := flowtest.NewStepper[*TBImpl](testLabel)
 
+	color.New(color.FgGreen).Fprintf(out, "== %s == Running\n", testLabel)
+	tb := &TBImpl{
+		context: ctx,
+		gate:    &testGate{},
+		test:    testReport,
+		quiet:   opts.Quiet,
+		out:     out,
+	}
+
+	timeout := opts.TestTimeout
+	if test.Timeout > 0 {
+		timeout = test.Timeout
+	}
+	stepper.SetTimeouts(flowtest.Timeouts{
+		Run:  timeout,
+		Step: opts.StepTimeout,
+		Hook: opts.HookTimeout,
+	})
+
+	test.Setup(stepper)
+
+	// The stepper fails and cancels the run itself when the timeout passes,
+	// the test is only abandoned if it then does not return.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
 		stepper.RunStepsWithContext(ctx, tb)
-		if tb.failed {
-			failures = append(failures, testLabel)
-			red("== Failed %s\n", testLabel)
-			cancel()
+	}()
+	if timeout > 0 {
+		select {
+		case <-done:
+		case <-time.After(timeout + abandonGrace):
+			tb.Log(fmt.Sprintf("abandoning %s, still running %s after the timeout", testLabel, abandonGrace))
+			tb.Fail()
+			// The abandoned steps keep running, so must no longer write to
+			// the output or the report which are now used by the caller.
+			tb.gate.close()
 		}
-		color.New(color.FgGreen).Printf("== Finished %s\n", testLabel)
+	} else {
+		<-done
 	}
 
-	if len(failures) > 0 {
-		red("Tests complete with %d failures:\n", len(failures))
-		for _, failure := range failures {
-			fmt.Printf(" - %s\n", failure)
+	failed := tb.Failed()
+	if failed {
+		color.New(color.FgRed).Fprintf(out, "== Failed %s\n", testLabel)
+	} else if tb.Skipped() {
+		testReport.SkipReason = tb.SkipReason()
+		if testReport.SkipReason == "" {
+			testReport.SkipReason = "skipped"
+		}
+	} else if allStepsSkipped(testReport) {
+		testReport.SkipReason = "all steps skipped"
+	}
+	if testReport.SkipReason != "" {
+		color.New(color.FgYellow).Fprintf(out, "== Skipped %s: %s\n", testLabel, testReport.SkipReason)
+	}
+	color.New(color.FgGreen).Fprintf(out, "== Finished %s\n", testLabel)
+	return failed, tb.Errors()
+}
+
+// allStepsSkipped returns true when the test has skipped steps, and every other
+// step is a teardown.
+func allStepsSkipped(testReport *TestReport) bool {
+	skipped := 0
+	for _, step := range testReport.Steps {
+		switch {
+		case step.Status == StatusSkipped:
+			skipped++
+		case !isTeardownStep(step.Name):
+			return false
+		}
+	}
+	return skipped > 0
+}
+
+// isTeardownStep matches the names of the teardown steps run by the stepper,
+// "teardown" or "vary 1 teardown".
+func isTeardownStep(name string) bool {
+	if _, ok := parseVariation(name); ok {
+		_, name, _ = strings.Cut(strings.TrimPrefix(name, "vary "), " ")
+	}
+	return name == "teardown"
+}
+
+// printSummary prints the counts of each status, and lists the tests which
+// failed or were skipped, returning an error if any failed.
+func printSummary(report *Report) error {
+	red := color.New(color.FgRed).PrintfFunc()
+	yellow := color.New(color.FgYellow).PrintfFunc()
+
+	counts := report.Counts()
+	fmt.Printf("Tests complete in %.3fs: %d passed, %d failed, %d skipped\n",
+		report.Duration, counts[StatusPassed], counts[StatusFailed], counts[StatusSkipped])
+
+	for _, test := range report.Tests {
+		if test.Status == StatusSkipped {
+			yellow(" - skipped %s: %s\n", test.Name, test.SkipReason)
+		}
+	}
+
+	for _, test := range report.Tests {
+		if test.Attempts > 1 {
+			yellow(" - flaky %s: %s after %d attempts\n", test.Name, test.Status, test.Attempts)
+		}
+	}
+
+	if counts[StatusFailed] > 0 {
+		red("Tests complete with %d failures:\n", counts[StatusFailed])
+		for _, test := range report.Tests {
+			if test.Status == StatusFailed {
+				fmt.Printf(" - %s\n", test.Name)
+			}
 		}
 
-		return fmt.Errorf("tests complete with %d failures", len(failures))
+		return fmt.Errorf("tests complete with %d failures", counts[StatusFailed])
 	}
 	return nil
 }
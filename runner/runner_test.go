@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pentops/flowtest"
+)
+
+func TestConcurrency(t *testing.T) {
+	barrier := sync.WaitGroup{}
+	barrier.Add(2)
+
+	waitForBoth := func(ss flowtest.StepSetter) {
+		ss.Step("wait", func(ctx context.Context, a flowtest.Asserter) {
+			barrier.Done()
+			done := make(chan struct{})
+			go func() {
+				barrier.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				a.Fatal("tests did not run concurrently")
+			}
+		})
+	}
+
+	afterBoth := false
+	ts := TestSet{}
+	ts.RegisterTest(Test{Name: "a", Setup: waitForBoth})
+	ts.RegisterTest(Test{Name: "b", Setup: waitForBoth})
+	ts.RegisterTest(Test{Name: "c", DependsOn: []string{"a", "b"}, Setup: func(ss flowtest.StepSetter) {
+		ss.Step("after", func(ctx context.Context, a flowtest.Asserter) {
+			afterBoth = true
+		})
+	}})
+
+	report, err := ts.RunWithOptions(context.Background(), RunOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !afterBoth {
+		t.Error("dependent test did not run")
+	}
+	if counts := report.Counts(); counts[StatusPassed] != 3 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+}
+
+func TestConcurrentVariations(t *testing.T) {
+	parallelLogs := func(name string) func(ss flowtest.StepSetter) {
+		return func(ss flowtest.StepSetter) {
+			ss.ParallelVariations()
+			for i := 0; i < 4; i++ {
+				ss.Variation(fmt.Sprintf("v%d", i), func(ctx context.Context, a flowtest.Asserter) {})
+			}
+			ss.Step("log", func(ctx context.Context, a flowtest.Asserter) {
+				for i := 0; i < 10; i++ {
+					a.Logf("%s line %d", name, i)
+				}
+			})
+		}
+	}
+
+	for _, quiet := range []bool{false, true} {
+		ts := TestSet{}
+		ts.RegisterTest(Test{Name: "alpha", Setup: parallelLogs("alpha")})
+		ts.RegisterTest(Test{Name: "beta", Setup: parallelLogs("beta")})
+
+		output := captureOutput(t)
+		report, err := ts.RunWithOptions(context.Background(), RunOptions{
+			Concurrency: 2,
+			Quiet:       quiet,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, test := range report.Tests {
+			lines := 0
+			for _, step := range test.Steps {
+				lines += len(step.Logs)
+			}
+			if lines != 40 {
+				t.Errorf("test %s logged %d lines, want 40", test.Name, lines)
+			}
+		}
+
+		// The output of each test is written in one piece, so the lines
+		// naming each test are not interleaved.
+		blocks := []string{}
+		for _, line := range strings.Split(output.String(), "\n") {
+			for _, name := range []string{"alpha", "beta"} {
+				if strings.Contains(line, name) && (len(blocks) == 0 || blocks[len(blocks)-1] != name) {
+					blocks = append(blocks, name)
+				}
+			}
+		}
+		if len(blocks) != 2 {
+			t.Errorf("quiet %v: test output is interleaved, got blocks %v:\n%s", quiet, blocks, output)
+		}
+	}
+}
+
+// captureOutput replaces color.Output, where the runner writes the output of
+// concurrent tests, until the test ends.
+func captureOutput(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	original := color.Output
+	color.Output = buf
+	t.Cleanup(func() {
+		color.Output = original
+	})
+	return buf
+}
@@ -5,18 +5,80 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/pentops/flowtest"
+	"github.com/pentops/flowtest/be"
 	"github.com/pentops/flowtest/runner/testclient"
 )
 
+// testGate serializes the output and report writes of a test and all of its
+// steps, which may run concurrently with parallel variations. Once a test is
+// abandoned the gate is closed, dropping everything the test still writes.
+type testGate struct {
+	lock   sync.Mutex
+	closed bool
+}
+
+// enter waits for the gate, returning false without holding it if the gate
+// is closed. A nil gate, for a TBImpl not created by the runner, is always
+// open and does not serialize.
+func (g *testGate) enter() bool {
+	if g == nil {
+		return true
+	}
+	g.lock.Lock()
+	if g.closed {
+		g.lock.Unlock()
+		return false
+	}
+	return true
+}
+
+func (g *testGate) exit() {
+	if g != nil {
+		g.lock.Unlock()
+	}
+}
+
+// close waits for any write in progress, then drops all further writes.
+func (g *testGate) close() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.closed = true
+}
+
 type TBImpl struct {
 	failed  bool
 	context context.Context
+	lock    sync.Mutex
+	gate    *testGate
+
+	// skipped is set by Skip, with the reason passed to it.
+	skipped    bool
+	skipReason string
+
+	out io.Writer
+
+	// quiet buffers the output of each step, printing it only if the step
+	// fails.
+	quiet bool
+
+	// test is set on the TBImpl running a whole test, and step on each TBImpl
+	// created by Run, to collect the results for the report.
+	test *TestReport
+	step *StepReport
+
+	// errors which caused failures, from this and all child steps.
+	errors []error
 }
 
 func (t *TBImpl) Helper() {}
@@ -27,6 +89,13 @@ var logColors = map[flowtest.LogLevel]color.Attribute{
 	flowtest.LogLevelDefault: color.FgWhite,
 }
 
+func (t *TBImpl) writer() io.Writer {
+	if t.out != nil {
+		return t.out
+	}
+	return color.Output
+}
+
 func (t *TBImpl) LevelLog(level flowtest.LogLevel, args ...any) {
 	var cc *color.Color
 	if logColor, ok := logColors[level]; ok {
@@ -34,17 +103,72 @@ func (t *TBImpl) LevelLog(level flowtest.LogLevel, args ...any) {
 	} else {
 		cc = color.New()
 	}
+
+	buf := &bytes.Buffer{}
+	formatLog(buf, args...)
+
+	prefix := ""
 	if level != flowtest.LogLevelDefault {
-		cc.Printf("%s: ", level)
+		prefix = cc.Sprintf("%s: ", level)
+	}
+
+	if !t.gate.enter() {
+		return
+	}
+	defer t.gate.exit()
+	fmt.Fprint(t.writer(), prefix+buf.String())
+
+	t.record(level, strings.TrimSuffix(buf.String(), "\n"))
+	if len(args) == 1 {
+		if outcome, ok := args[0].(*be.Outcome); ok && outcome != nil {
+			t.recordOutcome(outcome)
+		}
 	}
+}
+
+func formatLog(w io.Writer, args ...any) {
 	if len(args) == 1 {
 		switch arg := args[0].(type) {
 		case *testclient.RequestLog:
-			formatAPIResponse(arg)
+			formatAPIResponse(w, arg)
+			return
+		case *flowtest.GRPCLog:
+			formatGRPCLog(w, arg)
 			return
+		case *be.Outcome:
+			formatOutcome(w, arg)
+			return
+		}
+	}
+	fmt.Fprintln(w, args...)
+}
+
+// record adds the log line to the report of the step or test.
+func (t *TBImpl) record(level flowtest.LogLevel, line string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	isFailure := level == flowtest.LogLevelError || level == flowtest.LogLevelFatal
+	if t.step != nil {
+		t.step.Logs = append(t.step.Logs, line)
+		if isFailure {
+			t.step.Failures = append(t.step.Failures, line)
+		}
+	} else if t.test != nil {
+		t.test.Logs = append(t.test.Logs, line)
+		if isFailure {
+			t.test.Failures = append(t.test.Failures, line)
 		}
 	}
-	fmt.Println(args...)
+}
+
+func (t *TBImpl) recordOutcome(outcome *be.Outcome) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.step != nil {
+		t.step.Outcomes = append(t.step.Outcomes, newOutcomeReport(outcome))
+	} else if t.test != nil {
+		t.test.Outcomes = append(t.test.Outcomes, newOutcomeReport(outcome))
+	}
 }
 
 func (t *TBImpl) Context() context.Context {
@@ -56,19 +180,100 @@ func (t *TBImpl) Log(args ...any) {
 }
 
 func (t *TBImpl) Fail() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
 	t.failed = true
 }
 
+func (t *TBImpl) Failed() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.failed
+}
+
+// RecordError records an error which caused the test to fail.
+func (t *TBImpl) RecordError(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.errors = append(t.errors, err)
+}
+
+// Errors returns the errors which caused the test to fail.
+func (t *TBImpl) Errors() []error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return slices.Clone(t.errors)
+}
+
 func (t *TBImpl) FailNow() {
-	t.failed = true
+	t.Fail()
 	runtime.Goexit()
 }
 
+// Skip logs the args and marks the test or step as skipped, with the args as
+// the reason.
+func (t *TBImpl) Skip(args ...any) {
+	reason := strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+	if t.gate.enter() {
+		color.New(color.FgYellow).Fprintf(t.writer(), "SKIP: %s\n", reason)
+		t.record(flowtest.LogLevelDefault, "SKIP: "+reason)
+		t.gate.exit()
+	}
+	t.lock.Lock()
+	t.skipReason = reason
+	t.lock.Unlock()
+	t.SkipNow()
+}
+
+func (t *TBImpl) SkipNow() {
+	t.lock.Lock()
+	t.skipped = true
+	t.lock.Unlock()
+	runtime.Goexit()
+}
+
+func (t *TBImpl) Skipped() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.skipped
+}
+
+// SkipReason returns the reason passed to Skip.
+func (t *TBImpl) SkipReason() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.skipReason
+}
+
 func (t *TBImpl) Run(name string, f func(*TBImpl)) bool {
-	blue := color.New(color.FgBlue).PrintfFunc()
-	child := &TBImpl{}
+	step := &StepReport{
+		Name: name,
+	}
+	if variation, ok := parseVariation(name); ok {
+		step.Variation = &variation
+	}
+	child := &TBImpl{
+		context: t.context,
+		gate:    t.gate,
+		out:     t.out,
+		step:    step,
+	}
+	var quietBuffer *bytes.Buffer
+	if t.quiet {
+		quietBuffer = &bytes.Buffer{}
+		child.out = quietBuffer
+	}
 
-	blue("== STEP %s\n", name)
+	if t.gate.enter() {
+		t.lock.Lock()
+		if t.test != nil {
+			t.test.Steps = append(t.test.Steps, step)
+		}
+		t.lock.Unlock()
+		color.New(color.FgBlue).Fprintf(child.writer(), "== STEP %s\n", name)
+		t.gate.exit()
+	}
+	start := time.Now()
 	wg := sync.WaitGroup{}
 	wg.Add(1)
 	go func() {
@@ -76,41 +281,126 @@ func (t *TBImpl) Run(name string, f func(*TBImpl)) bool {
 		f(child)
 	}()
 	wg.Wait()
-	t.failed = t.failed || child.failed
 
-	return !child.failed
+	childFailed := child.Failed()
+	if t.gate.enter() {
+		child.lock.Lock()
+		step.Duration = time.Since(start).Seconds()
+		step.Status = StatusPassed
+		if childFailed {
+			step.Status = StatusFailed
+		} else if child.skipped {
+			step.Status = StatusSkipped
+			step.SkipReason = child.skipReason
+		}
+		child.lock.Unlock()
+		if childFailed && quietBuffer != nil {
+			_, _ = t.writer().Write(quietBuffer.Bytes())
+		}
+		t.gate.exit()
+	}
+
+	for _, err := range child.Errors() {
+		t.RecordError(err)
+	}
+
+	if childFailed {
+		t.Fail()
+	}
+
+	return !childFailed
 }
 
-func formatAPIResponse(ee *testclient.RequestLog) {
-	fmt.Printf("  %s %s\n", ee.Method, ee.Path)
+// parseVariation reads the variation index from the step names used by the
+// stepper, i.e. "vary 1 0 step name".
+func parseVariation(name string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, "vary ")
+	if !ok {
+		return 0, false
+	}
+	idxStr, _, _ := strings.Cut(rest, " ")
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil {
+		return 0, false
+	}
+	return idx, true
+}
+
+func formatOutcome(w io.Writer, outcome *be.Outcome) {
+	if outcome.Path != "" {
+		fmt.Fprintf(w, "%s: %s\n", outcome.Path, outcome.Message)
+	} else {
+		fmt.Fprintln(w, outcome.Message)
+	}
+	if outcome.Want != nil || outcome.Got != nil {
+		fmt.Fprintf(w, "  want: %v\n", outcome.Want)
+		fmt.Fprintf(w, "  got:  %v\n", outcome.Got)
+	}
+	if outcome.Diff != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(outcome.Diff, "\n"), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+func formatAPIResponse(w io.Writer, ee *testclient.RequestLog) {
+	fmt.Fprintf(w, "  %s %s\n", ee.Method, ee.Path)
 	for key, vals := range ee.RequestHeaders {
 		for _, val := range vals {
-			fmt.Printf("  | %s: %s\n", key, val)
+			fmt.Fprintf(w, "  | %s: %s\n", key, val)
 		}
 	}
 	if ee.RequestBody != nil {
 		formatted, _ := json.MarshalIndent(ee.RequestBody, "  | ", "  ")
-		fmt.Printf("  | %s\n", formatted)
+		fmt.Fprintf(w, "  | %s\n", formatted)
 	}
 	if ee.Error != nil {
-		fmt.Printf("  ERR: %s\n", ee.Error)
+		fmt.Fprintf(w, "  ERR: %s\n", ee.Error)
 	}
 	if ee.ResponseStatus != 0 {
-		fmt.Printf("  %d: %s\n", ee.ResponseStatus, http.StatusText(ee.ResponseStatus))
+		fmt.Fprintf(w, "  %d: %s\n", ee.ResponseStatus, http.StatusText(ee.ResponseStatus))
 	}
 	for key, vals := range ee.ResponseHeader {
 		for _, val := range vals {
-			fmt.Printf("  | %s: %s\n", key, val)
+			fmt.Fprintf(w, "  | %s: %s\n", key, val)
 		}
 	}
 	if ee.ResponseBody != nil {
 		rawBody, ok := ee.ResponseBody.([]byte)
 		if ok {
 			indentedBodyBytes := bytes.ReplaceAll(rawBody, []byte("\n"), []byte("\n  | "))
-			fmt.Printf("  | %s\n", indentedBodyBytes)
+			fmt.Fprintf(w, "  | %s\n", indentedBodyBytes)
 		} else {
 			formatted, _ := json.MarshalIndent(ee.ResponseBody, "  | ", "  ")
-			fmt.Printf("  | %s\n", formatted)
+			fmt.Fprintf(w, "  | %s\n", formatted)
+		}
+	}
+}
+
+func formatGRPCLog(w io.Writer, ee *flowtest.GRPCLog) {
+	fmt.Fprintf(w, "  gRPC %s\n", ee.Method)
+	for key, vals := range ee.RequestMetadata {
+		for _, val := range vals {
+			fmt.Fprintf(w, "  | %s: %s\n", key, val)
+		}
+	}
+	for _, req := range ee.Requests {
+		formatted, _ := json.MarshalIndent(req, "  | ", "  ")
+		fmt.Fprintf(w, "  | %s\n", formatted)
+	}
+	fmt.Fprintf(w, "  %s: %s\n", ee.Code, ee.Message)
+	for key, vals := range ee.ResponseHeader {
+		for _, val := range vals {
+			fmt.Fprintf(w, "  | %s: %s\n", key, val)
+		}
+	}
+	for _, res := range ee.Responses {
+		formatted, _ := json.MarshalIndent(res, "  | ", "  ")
+		fmt.Fprintf(w, "  | %s\n", formatted)
+	}
+	for key, vals := range ee.ResponseTrailer {
+		for _, val := range vals {
+			fmt.Fprintf(w, "  | %s: %s\n", key, val)
 		}
 	}
 }
@@ -0,0 +1,315 @@
+package testclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrUnmatchedRequest is returned, wrapped, by a replaying Cassette for a
+// request which matches no unused recorded interaction.
+var ErrUnmatchedRequest = errors.New("no recorded interaction matches request")
+
+type CassetteMode int
+
+const (
+	// ModeReplay serves responses from the cassette file without network.
+	ModeReplay CassetteMode = iota
+
+	// ModeRecord sends requests with the Transport and records every
+	// interaction, to be written by Save.
+	ModeRecord
+)
+
+// RecordedRequest is the part of a request stored in a cassette. Path
+// includes the query string.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a request and its response.
+type Interaction struct {
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response"`
+}
+
+type cassetteFile struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// RequestMatcher reports whether a request matches a recorded request.
+type RequestMatcher func(req, recorded *RecordedRequest) bool
+
+// CassetteOption configures a Cassette.
+type CassetteOption func(*Cassette)
+
+// IgnoreHeaders excludes the request headers from matching. Ignored headers,
+// of both requests and responses, are also not written to the cassette, so
+// credentials such as Authorization and Set-Cookie should be ignored.
+func IgnoreHeaders(names ...string) CassetteOption {
+	return func(c *Cassette) {
+		for _, name := range names {
+			c.ignoreHeaders = append(c.ignoreHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// IgnoreBody excludes the request body from matching.
+func IgnoreBody() CassetteOption {
+	return func(c *Cassette) {
+		c.ignoreBody = true
+	}
+}
+
+// MatchRequests replaces the default matching of method, path, headers and
+// body.
+func MatchRequests(matcher RequestMatcher) CassetteOption {
+	return func(c *Cassette) {
+		c.matcher = matcher
+	}
+}
+
+// Cassette is an http.RoundTripper which records interactions to a file, or
+// replays them from it. Use it as the Transport of API.Client, e.g. with
+// UseCassette.
+//
+// When replaying, each recorded interaction answers one request, in order, so
+// repeated identical requests get the responses recorded for them in turn.
+type Cassette struct {
+	// Transport sends requests when recording, defaulting to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+
+	path          string
+	mode          CassetteMode
+	ignoreHeaders []string
+	ignoreBody    bool
+	matcher       RequestMatcher
+
+	lock         sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// OpenCassette creates a cassette for the file at path, reading the recorded
+// interactions when replaying.
+func OpenCassette(path string, mode CassetteMode, opts ...CassetteOption) (*Cassette, error) {
+	c := &Cassette{
+		path: path,
+		mode: mode,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.matcher == nil {
+		c.matcher = c.defaultMatch
+	}
+
+	if mode == ModeRecord {
+		c.interactions = []*Interaction{}
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	file := &cassetteFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	c.interactions = file.Interactions
+	c.used = make([]bool, len(file.Interactions))
+	return c, nil
+}
+
+// UseCassette sends all requests of the API through the cassette. When
+// recording, the existing transport of the client is used to send them.
+func (api *API) UseCassette(c *Cassette) {
+	if api.Client == nil {
+		api.Client = &http.Client{}
+	}
+	if c.Transport == nil {
+		c.Transport = api.Client.Transport
+	}
+	api.Client.Transport = c
+}
+
+// RoundTrip does not modify req, the body is read and, when recording, sent
+// with a clone of the request.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, body, err := c.recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.mode == ModeRecord {
+		return c.record(req, body, recorded)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for idx, interaction := range c.interactions {
+		if c.used[idx] || !c.matcher(recorded, interaction.Request) {
+			continue
+		}
+		c.used[idx] = true
+		return interaction.Response.httpResponse(req), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrUnmatchedRequest, recorded.Method, recorded.Path)
+}
+
+// recordRequest reads and closes the request body, returning it along with
+// the request as stored in the cassette.
+func (c *Cassette) recordRequest(req *http.Request) (*RecordedRequest, []byte, error) {
+	recorded := &RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.RequestURI(),
+		Header: c.filterHeader(req.Header),
+	}
+	if req.Body == nil {
+		return recorded, nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading request body: %w", err)
+	}
+	recorded.Body = string(body)
+	return recorded, body, nil
+}
+
+func (c *Cassette) record(req *http.Request, body []byte, recorded *RecordedRequest) (*http.Response, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	send := req.Clone(req.Context())
+	if body != nil {
+		send.Body = io.NopCloser(bytes.NewReader(body))
+		send.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		send.ContentLength = int64(len(body))
+	}
+	resp, err := transport.RoundTrip(send)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	response := &RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     c.filterHeader(resp.Header),
+		Body:       string(respBody),
+	}
+
+	c.lock.Lock()
+	c.interactions = append(c.interactions, &Interaction{
+		Request:  recorded,
+		Response: response,
+	})
+	c.lock.Unlock()
+
+	// The caller gets the full response, only the cassette is filtered.
+	live := response.httpResponse(req)
+	live.Header = resp.Header.Clone()
+	return live, nil
+}
+
+func (c *Cassette) filterHeader(header http.Header) http.Header {
+	filtered := header.Clone()
+	for _, name := range c.ignoreHeaders {
+		filtered.Del(name)
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+func (c *Cassette) defaultMatch(req, recorded *RecordedRequest) bool {
+	if req.Method != recorded.Method || req.Path != recorded.Path {
+		return false
+	}
+	if !c.ignoreBody && req.Body != recorded.Body {
+		return false
+	}
+	return headersEqual(req.Header, c.filterHeader(recorded.Header))
+}
+
+func headersEqual(a, b http.Header) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, values := range a {
+		other := b[name]
+		if len(values) != len(other) {
+			return false
+		}
+		for idx := range values {
+			if values[idx] != other[idx] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *RecordedResponse) httpResponse(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// Save writes the recorded interactions to the cassette file, creating its
+// directory. It does nothing when replaying.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+	c.lock.Lock()
+	data, err := json.MarshalIndent(&cassetteFile{
+		Interactions: c.interactions,
+	}, "", "  ")
+	c.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,149 @@
+package testclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fooResponse struct {
+	ID    string `json:"id"`
+	Calls int    `json:"calls"`
+}
+
+func TestCassette(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "foo.json")
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+		json.NewEncoder(w).Encode(fooResponse{ID: r.URL.Path, Calls: calls}) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	recordAPI, err := NewAPI(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordAPI.Auth = BearerToken("secret")
+	recorder, err := OpenCassette(path, ModeRecord, IgnoreHeaders("Authorization", "Set-Cookie"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordAPI.UseCassette(recorder)
+
+	// RoundTrip must not modify the request.
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/foo/0", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqBody := req.Body
+	probe, err := OpenCassette(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe.Transport = server.Client().Transport
+	resp, err := probe.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if req.Body != reqBody {
+		t.Error("RoundTrip replaced the request body")
+	}
+	if resp.Header.Get("Set-Cookie") == "" {
+		t.Error("recording dropped an ignored header from the live response")
+	}
+	calls = 0
+
+	for _, reqPath := range []string{"/foo/1", "/foo/1", "/foo/2"} {
+		res := &fooResponse{}
+		if err := recordAPI.Request(ctx, http.MethodPost, reqPath, map[string]string{"a": "b"}, res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("ignored header recorded in cassette:\n%s", data)
+	}
+
+	// The server is closed, so responses can only come from the cassette.
+	replay := func(t *testing.T, opts ...CassetteOption) *API {
+		api, err := NewAPI(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		api.Auth = BearerToken("other")
+		cassette, err := OpenCassette(path, ModeReplay, append(opts, IgnoreHeaders("Authorization"))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		api.UseCassette(cassette)
+		return api
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		api := replay(t)
+		for idx, want := range []fooResponse{
+			{ID: "/foo/2", Calls: 3},
+			{ID: "/foo/1", Calls: 1},
+			{ID: "/foo/1", Calls: 2},
+		} {
+			res := &fooResponse{}
+			if err := api.Request(ctx, http.MethodPost, want.ID, map[string]string{"a": "b"}, res); err != nil {
+				t.Fatal(err)
+			}
+			if *res != want {
+				t.Errorf("request %d got %v, want %v", idx, res, want)
+			}
+		}
+
+		err := api.Request(ctx, http.MethodPost, "/foo/1", map[string]string{"a": "b"}, &fooResponse{})
+		if !errors.Is(err, ErrUnmatchedRequest) {
+			t.Errorf("got error %v, want unmatched", err)
+		}
+	})
+
+	t.Run("body", func(t *testing.T) {
+		api := replay(t)
+		err := api.Request(ctx, http.MethodPost, "/foo/1", map[string]string{"a": "c"}, &fooResponse{})
+		if !errors.Is(err, ErrUnmatchedRequest) {
+			t.Errorf("got error %v, want unmatched", err)
+		}
+
+		api = replay(t, IgnoreBody())
+		if err := api.Request(ctx, http.MethodPost, "/foo/1", map[string]string{"a": "c"}, &fooResponse{}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("matcher", func(t *testing.T) {
+		api := replay(t, MatchRequests(func(req, recorded *RecordedRequest) bool {
+			return recorded.Path == "/foo/2"
+		}))
+		res := &fooResponse{}
+		if err := api.Request(ctx, http.MethodGet, "/bar", nil, res); err != nil {
+			t.Fatal(err)
+		}
+		if res.ID != "/foo/2" {
+			t.Errorf("got %v", res)
+		}
+	})
+}
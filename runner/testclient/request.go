@@ -0,0 +1,257 @@
+package testclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// requestBody replaces the JSON body of a request.
+type requestBody struct {
+	contentType string
+	data        []byte
+
+	// log is the body as logged in RequestLog.
+	log any
+}
+
+// Query adds the values of the query parameter to the request.
+func Query(key string, values ...string) RequestOption {
+	return func(c *requestConfig) {
+		for _, value := range values {
+			c.query.Add(key, value)
+		}
+	}
+}
+
+// QueryValues adds all of the values to the request query.
+func QueryValues(values url.Values) RequestOption {
+	return func(c *requestConfig) {
+		for key, vals := range values {
+			for _, value := range vals {
+				c.query.Add(key, value)
+			}
+		}
+	}
+}
+
+// ProtoQuery adds the fields of the message to the request query, as
+// grpc-gateway decodes them: nested fields are joined with dots, repeated
+// fields repeat the parameter, map fields use the key in brackets, e.g.
+// labels[env]=prod, and values use their proto JSON encoding, e.g. enum names
+// and RFC 3339 timestamps. Unset fields are omitted. Repeated messages, map
+// values of messages and google.protobuf.Struct fields can not be encoded.
+func ProtoQuery(msg proto.Message) RequestOption {
+	return func(c *requestConfig) {
+		c.protoQueries = append(c.protoQueries, msg)
+	}
+}
+
+// Header sets the header on the request, replacing any previous values,
+// including those set by the API.Auth.
+func Header(key string, values ...string) RequestOption {
+	return func(c *requestConfig) {
+		c.header.Del(key)
+		for _, value := range values {
+			c.header.Add(key, value)
+		}
+	}
+}
+
+// RawBody sends data as the request body with the content type, in place of
+// the JSON body.
+func RawBody(contentType string, data []byte) RequestOption {
+	return func(c *requestConfig) {
+		c.body = &requestBody{
+			contentType: contentType,
+			data:        data,
+			log:         string(data),
+		}
+	}
+}
+
+// FormBody sends the values URL encoded as the request body.
+func FormBody(values url.Values) RequestOption {
+	return func(c *requestConfig) {
+		encoded := values.Encode()
+		c.body = &requestBody{
+			contentType: "application/x-www-form-urlencoded",
+			data:        []byte(encoded),
+			log:         encoded,
+		}
+	}
+}
+
+// MultipartFile is a file part of a MultipartBody.
+type MultipartFile struct {
+	Field    string
+	Filename string
+
+	// ContentType defaults to application/octet-stream.
+	ContentType string
+
+	Content []byte
+}
+
+// MultipartBody sends the fields and files as a multipart/form-data body.
+func MultipartBody(fields map[string]string, files ...MultipartFile) RequestOption {
+	return func(c *requestConfig) {
+		buf := &bytes.Buffer{}
+		mw := multipart.NewWriter(buf)
+		logged := map[string]string{}
+
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := mw.WriteField(key, fields[key]); err != nil {
+				c.err = err
+				return
+			}
+			logged[key] = fields[key]
+		}
+
+		for _, file := range files {
+			contentType := file.ContentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header := map[string][]string{
+				"Content-Disposition": {fmt.Sprintf(`form-data; name=%q; filename=%q`, file.Field, file.Filename)},
+				"Content-Type":        {contentType},
+			}
+			part, err := mw.CreatePart(header)
+			if err != nil {
+				c.err = err
+				return
+			}
+			if _, err := part.Write(file.Content); err != nil {
+				c.err = err
+				return
+			}
+			logged[file.Field] = fmt.Sprintf("%s (%s, %d bytes)", file.Filename, contentType, len(file.Content))
+		}
+
+		if err := mw.Close(); err != nil {
+			c.err = err
+			return
+		}
+		c.body = &requestBody{
+			contentType: mw.FormDataContentType(),
+			data:        buf.Bytes(),
+			log:         logged,
+		}
+	}
+}
+
+// encodeProtoQuery flattens the proto JSON encoding of the message into query
+// parameters, using the field descriptors to find map fields.
+func encodeProtoQuery(msg proto.Message, query url.Values) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	fields := map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return err
+	}
+	return flattenMessage("", msg.ProtoReflect().Descriptor(), fields, query)
+}
+
+func flattenMessage(prefix string, desc protoreflect.MessageDescriptor, fields map[string]any, query url.Values) error {
+	for name, value := range fields {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		field := desc.Fields().ByJSONName(name)
+		if field == nil {
+			return fmt.Errorf("query parameter %s: not a field of %s", key, desc.FullName())
+		}
+		if err := flattenField(key, field, value, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flattenField(key string, field protoreflect.FieldDescriptor, value any, query url.Values) error {
+	switch {
+	case field.IsMap():
+		entries, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("query parameter %s: expected a map", key)
+		}
+		for mapKey, item := range entries {
+			if _, ok := item.(map[string]any); ok {
+				return fmt.Errorf("query parameter %s: map values of messages can not be encoded", key)
+			}
+			if err := flattenValue(key+"["+mapKey+"]", field.MapValue(), item, query); err != nil {
+				return err
+			}
+		}
+	case field.IsList():
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("query parameter %s: expected a list", key)
+		}
+		for _, item := range items {
+			switch item.(type) {
+			case map[string]any, []any:
+				return fmt.Errorf("query parameter %s: repeated messages can not be encoded", key)
+			}
+			if err := flattenValue(key, field, item, query); err != nil {
+				return err
+			}
+		}
+	default:
+		return flattenValue(key, field, value, query)
+	}
+	return nil
+}
+
+func flattenValue(key string, field protoreflect.FieldDescriptor, value any, query url.Values) error {
+	switch value := value.(type) {
+	case map[string]any:
+		if msg := field.Message(); msg != nil && msg.FullName() != structName {
+			return flattenMessage(key, msg, value, query)
+		}
+		return fmt.Errorf("query parameter %s: %s can not be encoded", key, structName)
+	case []any:
+		return fmt.Errorf("query parameter %s: lists can not be encoded", key)
+	case string:
+		query.Add(key, value)
+	case nil:
+	default:
+		query.Add(key, fmt.Sprint(value))
+	}
+	return nil
+}
+
+// structName is google.protobuf.Struct, which is an arbitrary JSON object in
+// place of fields.
+const structName protoreflect.FullName = "google.protobuf.Struct"
+
+// withQuery appends the encoded query to the path, which may already have a
+// query string.
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		return path + "&" + query.Encode()
+	}
+	return path + "?" + query.Encode()
+}
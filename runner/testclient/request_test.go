@@ -0,0 +1,196 @@
+package testclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/monitoredres"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type echoResponse struct {
+	Method      string              `json:"method"`
+	Query       map[string][]string `json:"query"`
+	ContentType string              `json:"contentType"`
+	Header      string              `json:"header"`
+	Body        string              `json:"body"`
+	Form        map[string][]string `json:"form"`
+	File        string              `json:"file"`
+}
+
+func echoServer(t *testing.T) *API {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := echoResponse{
+			Method:      r.Method,
+			Query:       r.URL.Query(),
+			ContentType: r.Header.Get("Content-Type"),
+			Header:      r.Header.Get("X-Custom"),
+		}
+		if strings.HasPrefix(res.ContentType, "multipart/form-data") {
+			if err := r.ParseMultipartForm(1024); err != nil {
+				t.Error(err)
+			}
+			res.Form = r.MultipartForm.Value
+			if file, _, err := r.FormFile("upload"); err == nil {
+				data, _ := io.ReadAll(file)
+				res.File = string(data)
+			}
+		} else {
+			data, _ := io.ReadAll(r.Body)
+			res.Body = string(data)
+		}
+		json.NewEncoder(w).Encode(res) // nolint: errcheck
+	}))
+	t.Cleanup(server.Close)
+
+	api, err := NewAPI(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+func TestRequestOptions(t *testing.T) {
+	ctx := context.Background()
+	api := echoServer(t)
+
+	t.Run("query", func(t *testing.T) {
+		res := &echoResponse{}
+		err := api.Request(ctx, http.MethodGet, "/foo?a=1", nil, res,
+			Query("b", "2", "3"),
+			QueryValues(url.Values{"c": {"4"}}),
+			ProtoQuery(&descriptorpb.FieldDescriptorProto{
+				Name:    proto.String("field"),
+				Label:   descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Options: &descriptorpb.FieldOptions{Packed: proto.Bool(true)},
+			}),
+			ProtoQuery(&descriptorpb.EnumDescriptorProto{
+				ReservedName: []string{"x", "y"},
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string][]string{
+			"a":              {"1"},
+			"b":              {"2", "3"},
+			"c":              {"4"},
+			"name":           {"field"},
+			"label":          {"LABEL_REPEATED"},
+			"options.packed": {"true"},
+			"reservedName":   {"x", "y"},
+		}
+		if got, _ := json.Marshal(res.Query); string(got) != mustJSON(t, want) {
+			t.Errorf("got query %s, want %s", got, mustJSON(t, want))
+		}
+
+		err = api.Request(ctx, http.MethodGet, "/foo", nil, res, ProtoQuery(&descriptorpb.DescriptorProto{
+			Field: []*descriptorpb.FieldDescriptorProto{{Name: proto.String("field")}},
+		}))
+		if err == nil || !strings.Contains(err.Error(), "repeated messages") {
+			t.Errorf("got error %v", err)
+		}
+
+		res = &echoResponse{}
+		err = api.Request(ctx, http.MethodGet, "/foo", nil, res, ProtoQuery(&monitoredres.MonitoredResource{
+			Type:   "host",
+			Labels: map[string]string{"env": "prod", "zone": "a"},
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = map[string][]string{
+			"type":         {"host"},
+			"labels[env]":  {"prod"},
+			"labels[zone]": {"a"},
+		}
+		if got, _ := json.Marshal(res.Query); string(got) != mustJSON(t, want) {
+			t.Errorf("got query %s, want %s", got, mustJSON(t, want))
+		}
+
+		err = api.Request(ctx, http.MethodGet, "/foo", nil, res, ProtoQuery(&monitoredres.MonitoredResourceMetadata{
+			SystemLabels: &structpb.Struct{Fields: map[string]*structpb.Value{"a": structpb.NewStringValue("b")}},
+		}))
+		if err == nil || !strings.Contains(err.Error(), "google.protobuf.Struct") {
+			t.Errorf("got error %v", err)
+		}
+	})
+
+	t.Run("header", func(t *testing.T) {
+		res := &echoResponse{}
+		if err := api.Request(ctx, http.MethodGet, "/foo", nil, res, Header("X-Custom", "bar")); err != nil {
+			t.Fatal(err)
+		}
+		if res.Header != "bar" {
+			t.Errorf("got header %q", res.Header)
+		}
+	})
+
+	t.Run("delete body", func(t *testing.T) {
+		res := &echoResponse{}
+		if err := api.Request(ctx, http.MethodDelete, "/foo", map[string]string{"reason": "done"}, res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Method != http.MethodDelete || res.Body != `{"reason":"done"}` || res.ContentType != "application/json" {
+			t.Errorf("got %v", res)
+		}
+		if err := api.Request(ctx, http.MethodGet, "/foo", map[string]string{}, res); err == nil {
+			t.Error("got no error for GET with body")
+		}
+	})
+
+	t.Run("raw body", func(t *testing.T) {
+		res := &echoResponse{}
+		if err := api.Request(ctx, http.MethodPut, "/foo", nil, res, RawBody("text/csv", []byte("a,b\n"))); err != nil {
+			t.Fatal(err)
+		}
+		if res.Body != "a,b\n" || res.ContentType != "text/csv" {
+			t.Errorf("got %v", res)
+		}
+		if err := api.Request(ctx, http.MethodPut, "/foo", map[string]string{}, res, RawBody("text/csv", nil)); err == nil {
+			t.Error("got no error for both bodies")
+		}
+	})
+
+	t.Run("form body", func(t *testing.T) {
+		res := &echoResponse{}
+		if err := api.Request(ctx, http.MethodPost, "/foo", nil, res, FormBody(url.Values{"a": {"1 2"}})); err != nil {
+			t.Fatal(err)
+		}
+		if res.Body != "a=1+2" || res.ContentType != "application/x-www-form-urlencoded" {
+			t.Errorf("got %v", res)
+		}
+	})
+
+	t.Run("multipart body", func(t *testing.T) {
+		res := &echoResponse{}
+		err := api.Request(ctx, http.MethodPost, "/foo", nil, res, MultipartBody(
+			map[string]string{"a": "1"},
+			MultipartFile{Field: "upload", Filename: "foo.txt", Content: []byte("contents")},
+		))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Form["a"][0] != "1" || res.File != "contents" {
+			t.Errorf("got %v", res)
+		}
+	})
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
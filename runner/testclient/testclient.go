@@ -11,6 +11,8 @@ import (
 	"net/url"
 
 	"github.com/pentops/flowtest/be"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
 )
 
 type RequestLog struct {
@@ -32,6 +34,39 @@ type API struct {
 	Logger func(*RequestLog)
 
 	Auth AuthProvider
+
+	// LenientDecoding allows unknown fields in responses, unless overridden
+	// per request with DecodeStrict.
+	LenientDecoding bool
+}
+
+type requestConfig struct {
+	lenient      bool
+	query        url.Values
+	protoQueries []proto.Message
+	header       http.Header
+	body         *requestBody
+
+	// err is set by options which fail, and returned by Request.
+	err error
+}
+
+// RequestOption configures a single call to API.Request.
+type RequestOption func(*requestConfig)
+
+// DecodeLenient allows unknown fields in the response.
+func DecodeLenient() RequestOption {
+	return func(c *requestConfig) {
+		c.lenient = true
+	}
+}
+
+// DecodeStrict fails to decode responses with unknown fields, which is the
+// default unless API.LenientDecoding is set.
+func DecodeStrict() RequestOption {
+	return func(c *requestConfig) {
+		c.lenient = false
+	}
 }
 
 type AuthProvider interface {
@@ -62,7 +97,31 @@ func NewAPI(baseURL string) (*API, error) {
 	return api, nil
 }
 
-func (api *API) Request(ctx context.Context, method string, path string, body any, response any) error {
+// Request sends the body as JSON, and decodes a JSON response into response.
+// Any 2xx status is a success, and an empty body, e.g. with 204 No Content,
+// leaves response unchanged. Other statuses return an *APIError.
+//
+// A JSON body is accepted for PATCH, POST, PUT and DELETE. Options such as
+// RawBody replace the JSON body, and must be used with a nil body.
+func (api *API) Request(ctx context.Context, method string, path string, body any, response any, opts ...RequestOption) error {
+	config := &requestConfig{
+		lenient: api.LenientDecoding,
+		query:   url.Values{},
+		header:  http.Header{},
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.err != nil {
+		return config.err
+	}
+
+	for _, msg := range config.protoQueries {
+		if err := encodeProtoQuery(msg, config.query); err != nil {
+			return fmt.Errorf("encoding query: %w", err)
+		}
+	}
+	path = withQuery(path, config.query)
 
 	logEntry := &RequestLog{
 		Method:      method,
@@ -71,15 +130,24 @@ func (api *API) Request(ctx context.Context, method string, path string, body an
 	}
 
 	var bodyReader io.Reader
-	if body != nil {
+	contentType := ""
+	if config.body != nil {
+		if body != nil {
+			return fmt.Errorf("request has both a JSON body and a %s body", config.body.contentType)
+		}
+		bodyReader = bytes.NewReader(config.body.data)
+		contentType = config.body.contentType
+		logEntry.RequestBody = config.body.log
+	} else if body != nil {
 		switch method {
-		case http.MethodPatch, http.MethodPost, http.MethodPut:
+		case http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodDelete:
 
 			bodyBytes, err := json.Marshal(body)
 			if err != nil {
 				return fmt.Errorf("marshalling request: %w", err)
 			}
 			bodyReader = bytes.NewReader(bodyBytes)
+			contentType = "application/json"
 		default:
 			return fmt.Errorf("unsupported method %s with body", method)
 		}
@@ -92,8 +160,8 @@ func (api *API) Request(ctx context.Context, method string, path string, body an
 		return err
 	}
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
 	}
 
 	if api.Auth != nil {
@@ -103,6 +171,10 @@ func (api *API) Request(ctx context.Context, method string, path string, body an
 		}
 	}
 
+	for key, values := range config.header {
+		req.Header[key] = values
+	}
+
 	logEntry.RequestHeaders = req.Header
 
 	req = req.WithContext(ctx)
@@ -131,19 +203,19 @@ func (api *API) Request(ctx context.Context, method string, path string, body an
 	logEntry.ResponseStatus = resp.StatusCode
 	logEntry.ResponseBody = bodyBytes
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		if api.Logger != nil {
 			api.Logger(logEntry)
 		}
 
-		return &APIError{
-			StatusCode: resp.StatusCode,
-		}
+		return newAPIError(resp, bodyBytes)
 	}
 
-	if response != nil {
+	if response != nil && len(bytes.TrimSpace(bodyBytes)) > 0 {
 		dd := json.NewDecoder(bytes.NewReader(bodyBytes))
-		dd.DisallowUnknownFields()
+		if !config.lenient {
+			dd.DisallowUnknownFields()
+		}
 		err = dd.Decode(response)
 		if err != nil {
 			if api.Logger != nil {
@@ -163,17 +235,50 @@ func (api *API) Request(ctx context.Context, method string, path string, body an
 	return nil
 }
 
+// APIError is returned by API.Request for a response with a non 2xx status.
 type APIError struct {
 	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Status is the decoded body when it is a gRPC status, as returned by
+	// grpc-gateway, otherwise nil.
+	Status *GatewayStatus
+}
+
+// GatewayStatus is the JSON encoding of a google.rpc.Status used by
+// grpc-gateway for errors. Details are left encoded, as their types may not be
+// known.
+type GatewayStatus struct {
+	Code    codes.Code        `json:"code"`
+	Message string            `json:"message"`
+	Details []json.RawMessage `json:"details,omitempty"`
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+	// A status always has a code, as an error is never OK, so other JSON
+	// errors, e.g. {"message": "..."}, are not taken as a status.
+	st := &GatewayStatus{}
+	if err := json.Unmarshal(body, st); err == nil && st.Code != codes.OK {
+		apiErr.Status = st
+	}
+	return apiErr
 }
 
 func (e *APIError) Error() string {
+	if e.Status != nil {
+		return fmt.Sprintf("%s: %s: %s", http.StatusText(e.StatusCode), e.Status.Code, e.Status.Message)
+	}
 	return http.StatusText(e.StatusCode)
 }
 
 func failf(format string, args ...any) *be.Outcome {
-	fs := be.Outcome(fmt.Sprintf(format, args...))
-	return &fs
+	return be.Failf(format, args...)
 }
 
 func AssertHTTPError(err error, code int) *be.Outcome {
@@ -183,7 +288,11 @@ func AssertHTTPError(err error, code int) *be.Outcome {
 	apiErr := &APIError{}
 	if errors.As(err, &apiErr) {
 		if apiErr.StatusCode != code {
-			return failf("expected HTTP error %d, got %d", code, apiErr.StatusCode)
+			return &be.Outcome{
+				Message: fmt.Sprintf("expected HTTP error %d, got %d", code, apiErr.StatusCode),
+				Want:    code,
+				Got:     apiErr.StatusCode,
+			}
 		}
 	} else {
 		return failf("expected HTTP error %d, got %s", code, err)
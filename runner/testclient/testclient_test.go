@@ -0,0 +1,136 @@
+package testclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestRequestStatus(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "foo", "extra": true}`)) // nolint: errcheck
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/missing":
+			w.Header().Set("X-Request-Id", "req-1")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": 5, "message": "foo not found", "details": [{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "MISSING"}]}`)) // nolint: errcheck
+		case "/plain":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "bad input"}`)) // nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`upstream failed`)) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+
+	api, err := NewAPI(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type fooResponse struct {
+		ID string `json:"id"`
+	}
+
+	t.Run("created", func(t *testing.T) {
+		res := &fooResponse{}
+		err := api.Request(ctx, http.MethodPost, "/created", nil, res)
+		if err == nil {
+			t.Errorf("strict decoding got no error for unknown field")
+		}
+
+		res = &fooResponse{}
+		if err := api.Request(ctx, http.MethodPost, "/created", nil, res, DecodeLenient()); err != nil {
+			t.Fatal(err)
+		}
+		if res.ID != "foo" {
+			t.Errorf("got %v", res)
+		}
+	})
+
+	t.Run("lenient api", func(t *testing.T) {
+		lenient := *api
+		lenient.LenientDecoding = true
+		if err := lenient.Request(ctx, http.MethodPost, "/created", nil, &fooResponse{}); err != nil {
+			t.Error(err)
+		}
+		if err := lenient.Request(ctx, http.MethodPost, "/created", nil, &fooResponse{}, DecodeStrict()); err == nil {
+			t.Error("strict decoding got no error for unknown field")
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		res := &fooResponse{ID: "unchanged"}
+		if err := api.Request(ctx, http.MethodDelete, "/empty", nil, res); err != nil {
+			t.Fatal(err)
+		}
+		if res.ID != "unchanged" {
+			t.Errorf("got %v", res)
+		}
+	})
+
+	t.Run("gateway status", func(t *testing.T) {
+		err := api.Request(ctx, http.MethodGet, "/missing", nil, &fooResponse{})
+		apiErr := &APIError{}
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("got error %v", err)
+		}
+		if apiErr.StatusCode != http.StatusNotFound {
+			t.Errorf("got status %d", apiErr.StatusCode)
+		}
+		if got := apiErr.Header.Get("X-Request-Id"); got != "req-1" {
+			t.Errorf("got header %q", got)
+		}
+		if apiErr.Status == nil {
+			t.Fatal("got no status")
+		}
+		if apiErr.Status.Code != codes.NotFound || apiErr.Status.Message != "foo not found" || len(apiErr.Status.Details) != 1 {
+			t.Errorf("got status %v", apiErr.Status)
+		}
+		if got, want := err.Error(), "Not Found: NotFound: foo not found"; got != want {
+			t.Errorf("got message %q, want %q", got, want)
+		}
+		if outcome := AssertHTTPError(err, http.StatusNotFound); outcome != nil {
+			t.Error(outcome)
+		}
+	})
+
+	t.Run("message without code", func(t *testing.T) {
+		err := api.Request(ctx, http.MethodGet, "/plain", nil, &fooResponse{})
+		apiErr := &APIError{}
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("got error %v", err)
+		}
+		if apiErr.Status != nil {
+			t.Errorf("got status %v", apiErr.Status)
+		}
+		if got := err.Error(); got != "Bad Request" {
+			t.Errorf("got message %q", got)
+		}
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		err := api.Request(ctx, http.MethodGet, "/other", nil, &fooResponse{})
+		apiErr := &APIError{}
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("got error %v", err)
+		}
+		if apiErr.Status != nil {
+			t.Errorf("got status %v", apiErr.Status)
+		}
+		if string(apiErr.Body) != "upstream failed" {
+			t.Errorf("got body %q", apiErr.Body)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pentops/flowtest"
+)
+
+func TestTestTimeout(t *testing.T) {
+	ts := TestSet{}
+	ts.RegisterTest(Test{
+		Name:    "slow",
+		Timeout: 50 * time.Millisecond,
+		Setup: func(ss flowtest.StepSetter) {
+			ss.Step("wait", func(ctx context.Context, a flowtest.Asserter) {
+				<-ctx.Done()
+			})
+		},
+	})
+	ts.RegisterTest(Test{
+		Name: "fast",
+		Setup: func(ss flowtest.StepSetter) {
+			ss.Step("noop", func(ctx context.Context, a flowtest.Asserter) {})
+		},
+	})
+
+	report, err := ts.RunWithOptions(context.Background(), RunOptions{
+		KeepGoing:   true,
+		StepTimeout: time.Minute,
+	})
+	if err == nil {
+		t.Fatal("expected the slow test to fail")
+	}
+
+	slow, fast := report.Tests[0], report.Tests[1]
+	if slow.Status != StatusFailed {
+		t.Errorf("slow test %s", slow.Status)
+	}
+	if !strings.Contains(strings.Join(slow.Failures, "\n"), "timed out after 50ms") {
+		t.Errorf("unexpected failures %q", slow.Failures)
+	}
+	if fast.Status != StatusPassed {
+		t.Errorf("fast test %s", fast.Status)
+	}
+}
+
+func TestAbandonedTest(t *testing.T) {
+	defer func(grace time.Duration) {
+		abandonGrace = grace
+	}(abandonGrace)
+	abandonGrace = 50 * time.Millisecond
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
+
+	ts := TestSet{}
+	ts.RegisterTest(Test{
+		Name:    "stuck",
+		Timeout: 50 * time.Millisecond,
+		Setup: func(ss flowtest.StepSetter) {
+			ss.Step("ignores context", func(ctx context.Context, a flowtest.Asserter) {
+				defer close(stopped)
+				for {
+					select {
+					case <-stop:
+						return
+					case <-time.After(5 * time.Millisecond):
+						a.Log("still running")
+					}
+				}
+			})
+		},
+	})
+	ts.RegisterTest(Test{
+		Name: "other",
+		Setup: func(ss flowtest.StepSetter) {
+			ss.Step("noop", func(ctx context.Context, a flowtest.Asserter) {})
+		},
+	})
+
+	report, err := ts.RunWithOptions(context.Background(), RunOptions{
+		KeepGoing:   true,
+		Concurrency: 2,
+	})
+	if err == nil {
+		t.Fatal("expected the stuck test to fail")
+	}
+
+	// The abandoned step keeps logging, which must not reach the report.
+	stuck := report.Tests[0]
+	logs := len(stuck.Steps[0].Logs)
+	time.Sleep(50 * time.Millisecond)
+	if got := len(stuck.Steps[0].Logs); got != logs {
+		t.Errorf("abandoned step logged %d lines into the report", got-logs)
+	}
+	if stuck.Status != StatusFailed || !strings.Contains(strings.Join(stuck.Logs, "\n"), "abandoning") {
+		t.Errorf("unexpected report %+v", stuck)
+	}
+}
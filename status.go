@@ -0,0 +1,132 @@
+package flowtest
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// StatusMatcher checks part of a gRPC status for StatusError, returning a
+// description of the mismatch, or an empty string when it matches.
+type StatusMatcher func(st *status.Status) string
+
+// MessageContains matches a status with a message containing substr.
+func MessageContains(substr string) StatusMatcher {
+	return func(st *status.Status) string {
+		if strings.Contains(st.Message(), substr) {
+			return ""
+		}
+		return fmt.Sprintf("message %q does not contain %q", st.Message(), substr)
+	}
+}
+
+// HasErrorInfo matches a status with an ErrorInfo detail with the reason, and
+// the domain unless it is empty.
+func HasErrorInfo(reason, domain string) StatusMatcher {
+	return func(st *status.Status) string {
+		for _, detail := range st.Details() {
+			info, ok := detail.(*errdetails.ErrorInfo)
+			if !ok {
+				continue
+			}
+			if info.Reason == reason && (domain == "" || info.Domain == domain) {
+				return ""
+			}
+		}
+		if domain == "" {
+			return fmt.Sprintf("no ErrorInfo with reason %q", reason)
+		}
+		return fmt.Sprintf("no ErrorInfo with reason %q in domain %q", reason, domain)
+	}
+}
+
+// HasFieldViolation matches a status with a BadRequest detail with a
+// violation of the field at path, e.g. "order.items[0].sku".
+func HasFieldViolation(path string) StatusMatcher {
+	return func(st *status.Status) string {
+		fields := []string{}
+		for _, detail := range st.Details() {
+			badRequest, ok := detail.(*errdetails.BadRequest)
+			if !ok {
+				continue
+			}
+			for _, violation := range badRequest.FieldViolations {
+				if violation.Field == path {
+					return ""
+				}
+				fields = append(fields, violation.Field)
+			}
+		}
+		return fmt.Sprintf("no field violation for %q, got %v", path, fields)
+	}
+}
+
+// HasPreconditionFailure matches a status with a PreconditionFailure detail
+// with a violation of the type, and the subject unless it is empty.
+func HasPreconditionFailure(violationType, subject string) StatusMatcher {
+	return func(st *status.Status) string {
+		for _, detail := range st.Details() {
+			failure, ok := detail.(*errdetails.PreconditionFailure)
+			if !ok {
+				continue
+			}
+			for _, violation := range failure.Violations {
+				if violation.Type == violationType && (subject == "" || violation.Subject == subject) {
+					return ""
+				}
+			}
+		}
+		if subject == "" {
+			return fmt.Sprintf("no precondition failure of type %q", violationType)
+		}
+		return fmt.Sprintf("no precondition failure of type %q for %q", violationType, subject)
+	}
+}
+
+// HasDetail matches a status with a detail equal to want.
+func HasDetail(want proto.Message) StatusMatcher {
+	return func(st *status.Status) string {
+		for _, detail := range st.Details() {
+			if got, ok := detail.(proto.Message); ok && proto.Equal(got, want) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("no detail equal to %s %s", want.ProtoReflect().Descriptor().FullName(), prototext.Format(want))
+	}
+}
+
+func (a *assertion) StatusError(err error, code codes.Code, matchers ...StatusMatcher) {
+	a.helper()
+	if err == nil {
+		a.fail("got no error, want code %s", code)
+		return
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		a.recordError(err)
+		a.fail("got error %s (%T), want code %s", err, err, code)
+		return
+	}
+
+	mismatches := []string{}
+	if st.Code() != code {
+		mismatches = append(mismatches, fmt.Sprintf("got code %s, want %s", st.Code(), code))
+	}
+	for _, matcher := range matchers {
+		if mismatch := matcher(st); mismatch != "" {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	if len(mismatches) == 0 {
+		return
+	}
+
+	a.recordError(err)
+	a.fail("status mismatch:\n  %s\n%s", strings.Join(mismatches, "\n  "), prototext.MarshalOptions{Multiline: true, Indent: "  "}.Format(st.Proto()))
+}
@@ -0,0 +1,105 @@
+package flowtest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid order: sku is required").WithDetails(
+		&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       "order.items[0].sku",
+				Description: "required",
+			}},
+		},
+		&errdetails.ErrorInfo{
+			Reason: "MISSING_SKU",
+			Domain: "orders.example.com",
+		},
+		&errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:    "STOCK",
+				Subject: "sku/123",
+			}},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statusErr := st.Err()
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		code     codes.Code
+		matchers []StatusMatcher
+		failure  []string
+	}{{
+		name: "all match",
+		err:  statusErr,
+		code: codes.InvalidArgument,
+		matchers: []StatusMatcher{
+			MessageContains("sku is required"),
+			HasFieldViolation("order.items[0].sku"),
+			HasErrorInfo("MISSING_SKU", ""),
+			HasErrorInfo("MISSING_SKU", "orders.example.com"),
+			HasPreconditionFailure("STOCK", "sku/123"),
+			HasDetail(&errdetails.ErrorInfo{Reason: "MISSING_SKU", Domain: "orders.example.com"}),
+		},
+	}, {
+		name: "mismatches",
+		err:  statusErr,
+		code: codes.NotFound,
+		matchers: []StatusMatcher{
+			MessageContains("not found"),
+			HasFieldViolation("order.id"),
+			HasErrorInfo("MISSING_SKU", "other.example.com"),
+		},
+		failure: []string{
+			"got code InvalidArgument, want NotFound",
+			`message "invalid order: sku is required" does not contain "not found"`,
+			`no field violation for "order.id", got [order.items[0].sku]`,
+			`no ErrorInfo with reason "MISSING_SKU" in domain "other.example.com"`,
+			// the status dump
+			"[type.googleapis.com/google.rpc.ErrorInfo]",
+		},
+	}, {
+		name:    "no error",
+		code:    codes.NotFound,
+		failure: []string{"got no error, want code NotFound"},
+	}, {
+		name:    "not a status",
+		err:     errors.New("plain"),
+		code:    codes.NotFound,
+		failure: []string{"got error plain"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWrap{}
+			a := &assertion{
+				fatal:  tw.Fatal,
+				helper: tw.Helper,
+			}
+			a.StatusError(tc.err, tc.code, tc.matchers...)
+			if len(tc.failure) == 0 {
+				if tw.failed {
+					t.Errorf("unexpected failure: %s", tw.message)
+				}
+				return
+			}
+			if !tw.failed {
+				t.Fatal("did not fail")
+			}
+			for _, want := range tc.failure {
+				if !strings.Contains(tw.message, want) {
+					t.Errorf("failure does not contain %q:\n%s", want, tw.message)
+				}
+			}
+		})
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"log/slog"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Asserter interface {
@@ -18,14 +21,21 @@ type Logger interface {
 	Log(args ...any)
 }
 
-type shiftingLogger struct {
+// ContextLogger is a Logger which can use the context of the caller to find
+// where the log line belongs, e.g. the variation of a Stepper which made a
+// call.
+type ContextLogger interface {
 	Logger
+	LogContext(ctx context.Context, args ...any)
+}
+
+type shiftingLogger struct {
+	ContextLogger
 }
 
 type step struct {
-	desc     string
-	asserter *stepRun
-	fn       callback
+	desc string
+	fn   callback
 }
 
 type callback func(context.Context, Asserter)
@@ -34,14 +44,22 @@ type callbackErr func(context.Context, Asserter) error
 type Stepper[T RequiresTB] struct {
 	steps      []*step
 	variations []*step
-	asserter   *stepRun
 	name       string
+	parallel   bool
+	onFailure  FailurePolicy
+	timeouts   Timeouts
+
+	// asserter is the most recently started step or hook across all
+	// variations, used when logging without a context.
+	asserter *stepRun
+	lock     sync.Mutex
 
 	setup             []callbackErr
 	backgroundHooks   []callbackErr
 	preStepHooks      []callbackErr
 	preVariationHooks []callbackErr
 	postStepHooks     []callbackErr
+	teardownHooks     []callbackErr
 
 	shiftingLogger *shiftingLogger
 }
@@ -82,6 +100,14 @@ func (ss *Stepper[T]) PostStepHook(fn callbackErr) {
 	ss.postStepHooks = append(ss.postStepHooks, fn)
 }
 
+// Teardown runs at the end of each RunSteps call, or the end of each Variation,
+// after the last step, and also after any failure. Teardown hooks, and any
+// Cleanup functions registered on the asserters, run in the reverse order of
+// registration, each one running even when a previous one fails.
+func (ss *Stepper[T]) Teardown(fn callbackErr) {
+	ss.teardownHooks = append(ss.teardownHooks, fn)
+}
+
 // Step registers a function to make assertions on the running code, this is the
 // main assertion set.
 func (ss *Stepper[_]) Step(desc string, fn callback) {
@@ -101,12 +127,48 @@ func (ss *Stepper[_]) Variation(desc string, fn callback) {
 	})
 }
 
-// FollowAsserter returns an asserter which always points to the current test.
+// ParallelVariations runs each Variation concurrently rather than one after
+// the other. Each variation has its own Setup and Background hooks, and its own
+// step state, so variations must not share mutable fixtures.
+func (ss *Stepper[_]) ParallelVariations() {
+	ss.parallel = true
+}
+
+// FailurePolicy controls what the stepper does with the remaining steps and
+// variations after a step fails.
+type FailurePolicy int
+
+const (
+	// FailFast stops the run at the first failure, no further steps or
+	// variations are run. This is the default. With ParallelVariations, the
+	// context of every other variation is canceled, and they run no further
+	// steps.
+	FailFast FailurePolicy = iota
+
+	// ContinueVariations stops the variation which failed, but still runs
+	// every other variation.
+	ContinueVariations
+
+	// ReportSkipped behaves as ContinueVariations, and also reports every
+	// step which did not run in the failed variation as skipped, naming the
+	// step which failed.
+	ReportSkipped
+)
+
+// OnFailure sets the FailurePolicy of the stepper.
+func (ss *Stepper[_]) OnFailure(policy FailurePolicy) {
+	ss.onFailure = policy
+}
+
+// ShiftingLogger returns a logger which always points to the current test.
 // It is valid only as long as the stepper is valid.
+// The returned logger is a ContextLogger, when variations run in parallel
+// LogContext should be used with the context passed to the step to route the
+// log to the correct variation.
 func (ss *Stepper[T]) ShiftingLogger() Logger {
 	if ss.shiftingLogger == nil {
 		ss.shiftingLogger = &shiftingLogger{
-			Logger: ss,
+			ContextLogger: ss,
 		}
 	}
 	return ss.shiftingLogger
@@ -130,6 +192,10 @@ type StepSetter interface {
 	// PostStepHook runs after every step.
 	PostStepHook(fn callbackErr)
 
+	// Teardown runs at the end of each RunSteps call, or the end of each
+	// Variation, even after a failure, in reverse order of registration.
+	Teardown(fn callbackErr)
+
 	// Step registers a function to make assertions on the running code, this is the
 	// main assertion set.
 	Step(desc string, fn callback)
@@ -139,6 +205,17 @@ type StepSetter interface {
 	// one call to RunSteps to run multiple variations of the same test.
 	Variation(desc string, fn callback)
 
+	// ParallelVariations runs each Variation concurrently rather than one
+	// after the other.
+	ParallelVariations()
+
+	// OnFailure sets what happens to the remaining steps and variations after
+	// a step fails.
+	OnFailure(policy FailurePolicy)
+
+	// SetTimeouts limits how long the run, each step and each hook may take.
+	SetTimeouts(timeouts Timeouts)
+
 	// LevelLog implements a global logger compatible with pentops/log.go/log.
 	// Log lines will be captured into the currently running test step.
 	LevelLog(level, message string, attrs []slog.Attr)
@@ -148,21 +225,46 @@ type StepSetter interface {
 	Log(...any)
 }
 
+// currentAsserter returns the asserter of the running step for the variation
+// in the context, falling back to the most recently started step.
+func (ss *Stepper[T]) currentAsserter(ctx context.Context) *stepRun {
+	if asserter := asserterFromContext(ctx); asserter != nil {
+		return asserter
+	}
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+	return ss.asserter
+}
+
 func (ss *Stepper[T]) Log(args ...any) {
-	if ss.asserter == nil {
+	ss.LogContext(context.Background(), args...)
+}
+
+// LogContext logs into the step running in the variation of the given
+// context.
+func (ss *Stepper[T]) LogContext(ctx context.Context, args ...any) {
+	asserter := ss.currentAsserter(ctx)
+	if asserter == nil {
 		fmt.Printf("WARNING: Log called on stepper without a current step. %s", fmt.Sprint(args...))
 		return
 	}
-	ss.asserter.helper()
-	ss.asserter.Log(args...)
+	asserter.helper()
+	asserter.Log(args...)
 }
 
 // LevelLog implements a global logger compatible with pentops/log.go/log
 // DefaultLogger, and others, to capture log lines from within the handlers
 // into the test output
 func (ss *Stepper[T]) LevelLog(level, message string, fields []slog.Attr) {
-	if ss.asserter != nil {
-		ss.asserter.helper()
+	ss.LevelLogContext(context.Background(), level, message, fields)
+}
+
+// LevelLogContext is LevelLog, routed by the context when variations run in
+// parallel.
+func (ss *Stepper[T]) LevelLogContext(ctx context.Context, level, message string, fields []slog.Attr) {
+	asserter := ss.currentAsserter(ctx)
+	if asserter != nil {
+		asserter.helper()
 	}
 
 	fieldStrings := make([]string, 0, len(fields)+1)
@@ -176,24 +278,24 @@ func (ss *Stepper[T]) LevelLog(level, message string, fields []slog.Attr) {
 		}
 		fieldStrings = append(fieldStrings, fmt.Sprintf("%s: %v", k, v))
 	}
-	if ss.asserter == nil {
+	if asserter == nil {
 		fmt.Printf("WARNING: Log called on stepper without a current step (level: %s and message: %s)\n   |%s\n", level, message, strings.Join(fieldStrings, "\n   |"))
 		return
 	}
-	ss.Log(strings.Join(fieldStrings, "\n"))
+	asserter.Log(strings.Join(fieldStrings, "\n"))
 
 }
 
 const maxStackLen = 50
 
 func (ss *Stepper[T]) LogQuery(ctx context.Context, statement string, params ...any) {
-	if ss.asserter != nil {
-		ss.asserter.helper()
+	if asserter := ss.currentAsserter(ctx); asserter != nil {
+		asserter.helper()
 	}
 	var pc [maxStackLen]uintptr
 	n := runtime.Callers(4, pc[:])
 	if n == 0 {
-		ss.Log("No stack available")
+		ss.LogContext(ctx, "No stack available")
 		return
 	}
 	frames := runtime.CallersFrames(pc[:])
@@ -222,7 +324,7 @@ func (ss *Stepper[T]) LogQuery(ctx context.Context, statement string, params ...
 		}
 		lines = append(lines, fmt.Sprintf("  $%d %#v", i+1, param))
 	}
-	ss.Log(strings.Join(lines, "\n"))
+	ss.LogContext(ctx, strings.Join(lines, "\n"))
 }
 
 // RunSteps is the main entry point of the stepper. For each Variation, or just
@@ -238,47 +340,91 @@ func (ss *Stepper[T]) RunSteps(t RunnableTB[T]) {
 func (ss *Stepper[T]) RunStepsWithContext(ctx context.Context, t RunnableTB[T]) {
 	t.Helper()
 
-	if len(ss.variations) > 0 {
-		for variationIdx, variation := range ss.variations {
-			success := ss.runVariation(ctx, t, variationIdx, variation)
-			if !success {
-				return
-			}
+	if ss.timeouts.Run > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ss.timeouts.Run)
+		defer cancel()
+		stop := startWatchdog(t, ss.timeouts.Run, fmt.Sprintf("run %s", ss.name), cancel)
+		defer stop()
+	}
 
-		}
+	if len(ss.variations) == 0 {
+		ss.runVariation(ctx, t, 0, nil)
 		return
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	if err := ss.runHooks(ctx, cancel, t, ss.setup...); err != nil {
-		t.Log("Setup failed", err)
-		t.FailNow()
+	if ss.parallel {
+		// With FailFast, the first failure cancels the other variations, which
+		// then run no further steps.
+		ctx, stop := context.WithCancelCause(ctx)
+		defer stop(nil)
+		wg := sync.WaitGroup{}
+		for variationIdx, variation := range ss.variations {
+			variationIdx, variation := variationIdx, variation
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				success := ss.runVariation(ctx, detachedTB[T]{RunnableTB: t}, variationIdx, variation)
+				if !success && ss.onFailure == FailFast {
+					stop(errVariationFailed)
+				}
+			}()
+		}
+		wg.Wait()
+		return
 	}
 
-	backgroundCtx, backgroundCancel := context.WithCancel(ctx)
-	defer backgroundCancel()
-	chBackgroundErr := make(chan error)
-	go func() {
-		err := ss.runHooks(backgroundCtx, cancel, t, ss.backgroundHooks...)
-		chBackgroundErr <- err
-	}()
-
-	for idx, step := range ss.steps {
-		success := ss.runStep(ctx, t, fmt.Sprintf("%d %s", idx, step.desc), step, ss.preStepHooks, ss.postStepHooks)
-		if !success {
+	for variationIdx, variation := range ss.variations {
+		success := ss.runVariation(ctx, t, variationIdx, variation)
+		if !success && ss.onFailure == FailFast {
 			return
 		}
 	}
+}
 
-	backgroundCancel()
-	if err := <-chBackgroundErr; err != nil {
-		if errors.Is(err, context.Canceled) {
-			return
-		}
-		t.Log("Background hook failed", err)
-		t.FailNow()
+// errVariationFailed is the cause of the context of parallel variations which
+// are stopped by the failure of another.
+var errVariationFailed = errors.New("another variation failed")
+
+// runState holds the state of a single variation, or the whole run when there
+// are no variations, and is stored in the context passed to the steps.
+type runState struct {
+	lock     sync.Mutex
+	asserter *stepRun
+	cleanups []callbackErr
+}
+
+type runStateKey struct{}
+
+// asserterFromContext returns the asserter of the running step for the
+// variation in the context, or nil if the context is not from a stepper.
+func asserterFromContext(ctx context.Context) *stepRun {
+	if state, ok := ctx.Value(runStateKey{}).(*runState); ok {
+		return state.current()
 	}
+	return nil
+}
+
+func (rs *runState) current() *stepRun {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	return rs.asserter
+}
+
+func (rs *runState) addCleanup(fn callbackErr) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.cleanups = append(rs.cleanups, fn)
+}
+
+// popCleanups returns the registered cleanups in the order they should run.
+func (rs *runState) popCleanups() []callbackErr {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	cleanups := rs.cleanups
+	rs.cleanups = nil
+	slices.Reverse(cleanups)
+	return cleanups
 }
 
 func (ss *Stepper[T]) buildAsserter(ctx context.Context, t RequiresTB, cancel func()) *stepRun {
@@ -288,32 +434,172 @@ func (ss *Stepper[T]) buildAsserter(ctx context.Context, t RequiresTB, cancel fu
 		context:    ctx,
 	}
 	asserter.assertion = asserter.anon()
+
+	if state, ok := ctx.Value(runStateKey{}).(*runState); ok {
+		state.lock.Lock()
+		state.asserter = asserter
+		state.lock.Unlock()
+	}
+
+	ss.lock.Lock()
 	ss.asserter = asserter
+	ss.lock.Unlock()
 	return asserter
 }
 
+// runVariation runs the hooks and steps for a single variation. When variation
+// is nil, the steps are run without any variation.
 func (ss *Stepper[T]) runVariation(ctx context.Context, t RunnableTB[T], variationIdx int, variation *step) bool {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	state := &runState{
+		cleanups: slices.Clone(ss.teardownHooks),
+	}
+	ctx = context.WithValue(ctx, runStateKey{}, state)
 
-	if err := ss.runHooks(ctx, cancel, t, ss.setup...); err != nil {
-		t.Log("Setup failed", err)
-		t.FailNow()
+	type namedStep struct {
+		name     string
+		step     *step
+		preHooks []callbackErr
+		post     []callbackErr
+	}
+	steps := make([]namedStep, 0, len(ss.steps)+1)
+	prefix := ""
+	if variation != nil {
+		prefix = fmt.Sprintf("vary %d ", variationIdx)
+		steps = append(steps, namedStep{
+			name:     fmt.Sprintf("vary %d %s", variationIdx, variation.desc),
+			step:     variation,
+			preHooks: ss.preVariationHooks,
+		})
+	}
+	for idx, step := range ss.steps {
+		steps = append(steps, namedStep{
+			name:     fmt.Sprintf("%s%d %s", prefix, idx, step.desc),
+			step:     step,
+			preHooks: ss.preStepHooks,
+			post:     ss.postStepHooks,
+		})
 	}
 
-	success := ss.runStep(ctx, t, fmt.Sprintf("vary %d %s", variationIdx, variation.desc), variation, ss.preVariationHooks, nil)
-	if !success {
+	teardownName := "teardown"
+	if variation != nil {
+		teardownName = fmt.Sprintf("vary %d teardown", variationIdx)
+	}
+	defer ss.runTeardown(context.WithoutCancel(ctx), t, teardownName, state)
+
+	skipRemaining := func(from int, reason string) {
+		if ss.onFailure != ReportSkipped {
+			return
+		}
+		for _, step := range steps[from:] {
+			ss.skipStep(t, step.name, reason)
+		}
+	}
+
+	stopWatchdog := startWatchdog(t, ss.timeouts.Hook, fmt.Sprintf("%sSetup", prefix), cancel)
+	err := ss.runHooks(ctx, cancel, t, ss.setup...)
+	stopWatchdog()
+	if err != nil {
+		t.Log("Setup failed", err)
+		recordError(t, err)
+		t.Fail()
+		skipRemaining(0, "skipped because Setup failed")
 		return false
 	}
-	for idx, step := range ss.steps {
-		success := ss.runStep(ctx, t, fmt.Sprintf("vary %d %d %s", variationIdx, idx, step.desc), step, ss.preStepHooks, ss.postStepHooks)
+
+	backgroundCtx, backgroundCancel := context.WithCancel(ctx)
+	defer backgroundCancel()
+	chBackgroundErr := make(chan error, 1)
+	go func() {
+		err := ss.runHooks(backgroundCtx, cancel, t, ss.backgroundHooks...)
+		chBackgroundErr <- err
+	}()
+
+	for idx, step := range steps {
+		if context.Cause(ctx) == errVariationFailed {
+			t.Log(fmt.Sprintf("%s not run because %s", step.name, errVariationFailed))
+			return false
+		}
+		success, skipped := ss.runStep(ctx, t, step.name, step.step, step.preHooks, step.post)
 		if !success {
+			skipRemaining(idx+1, fmt.Sprintf("skipped because step %s failed", step.name))
 			return false
 		}
+		if skipped && step.step == variation {
+			// Skipping the variation skips all of its steps, which are always
+			// reported as skipped as they are not failures.
+			for _, remaining := range steps[idx+1:] {
+				ss.skipStep(t, remaining.name, fmt.Sprintf("skipped because %s skipped", step.name))
+			}
+			break
+		}
+	}
+
+	backgroundCancel()
+	var backgroundTimeout <-chan time.Time
+	if ss.timeouts.Hook > 0 {
+		backgroundTimeout = time.After(ss.timeouts.Hook)
+	}
+	select {
+	case err = <-chBackgroundErr:
+	case <-backgroundTimeout:
+		logAtLevel(t, LogLevelError, timeoutMessage(fmt.Sprintf("%sBackground hook", prefix), ss.timeouts.Hook))
+		t.Fail()
+		return false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return true
+		}
+		t.Log("Background hook failed", err)
+		recordError(t, err)
+		t.Fail()
+		return false
 	}
 	return true
 }
 
+// runTeardown runs the teardown hooks and cleanups of a variation as a separate
+// step, so that failures are reported apart from the steps.
+func (ss *Stepper[T]) runTeardown(ctx context.Context, t RunnableTB[T], name string, state *runState) {
+	cleanups := state.popCleanups()
+	if len(cleanups) == 0 {
+		return
+	}
+
+	t.Run(name, func(t T) {
+		for _, fn := range cleanups {
+			fn := fn
+			hookCtx, cancel := context.WithCancel(ctx)
+			var timeout <-chan time.Time
+			if ss.timeouts.Hook > 0 {
+				hookCtx, cancel = context.WithTimeout(ctx, ss.timeouts.Hook)
+				timeout = time.After(ss.timeouts.Hook)
+			}
+
+			// Each cleanup runs in its own goroutine, so that FailNow ends only
+			// that cleanup, and a cleanup which does not return can be
+			// abandoned.
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				asserter := ss.buildAsserter(hookCtx, goexitTB{RequiresTB: t}, cancel)
+				if err := fn(hookCtx, asserter); err != nil {
+					asserter.Error("Teardown failed", err)
+				}
+			}()
+			select {
+			case <-done:
+			case <-timeout:
+				logAtLevel(t, LogLevelError, timeoutMessage("Teardown hook", ss.timeouts.Hook))
+				t.Fail()
+			}
+			cancel()
+		}
+	})
+}
+
 func (ss *Stepper[T]) runHooks(ctx context.Context, cancel func(), t RunnableTB[T], fns ...callbackErr) error {
 	if len(fns) == 0 {
 		return nil
@@ -331,21 +617,29 @@ func (ss *Stepper[T]) runHooks(ctx context.Context, cancel func(), t RunnableTB[
 	return nil
 }
 
-func (ss *Stepper[T]) runStep(ctx context.Context, t RunnableTB[T], name string, step *step, preHooks []callbackErr, postHooks []callbackErr) bool {
+// runStep runs a single step as a sub test, returning false if the step failed,
+// and whether the step was skipped.
+func (ss *Stepper[T]) runStep(ctx context.Context, t RunnableTB[T], name string, step *step, preHooks []callbackErr, postHooks []callbackErr) (bool, bool) {
 	ctx, cancel := context.WithCancel(ctx)
+	if ss.timeouts.Step > 0 {
+		ctx, cancel = context.WithTimeout(ctx, ss.timeouts.Step)
+	}
 	defer cancel()
 
 	actuallyDidRun := false
+	var asserter *stepRun
 	success := t.Run(name, func(t T) {
 		actuallyDidRun = true
+		stopWatchdog := startWatchdog(t, ss.timeouts.Step, fmt.Sprintf("step %s", name), cancel)
+		defer stopWatchdog()
 
-		asserter := ss.buildAsserter(ctx, t, cancel)
-		step.asserter = asserter
+		asserter = ss.buildAsserter(ctx, t, cancel)
 
 		for _, hook := range preHooks {
-			err := hook(ctx, ss.asserter)
+			err := hook(ctx, asserter)
 			if err != nil {
 				t.Log("Pre hook failed", err)
+				recordError(t, err)
 				t.FailNow()
 			}
 		}
@@ -353,9 +647,10 @@ func (ss *Stepper[T]) runStep(ctx context.Context, t RunnableTB[T], name string,
 		step.fn(ctx, asserter)
 
 		for _, hook := range postHooks {
-			err := hook(ctx, ss.asserter)
+			err := hook(ctx, asserter)
 			if err != nil {
 				t.Log("Post hook failed", err)
+				recordError(t, err)
 				t.FailNow()
 			}
 		}
@@ -365,14 +660,90 @@ func (ss *Stepper[T]) runStep(ctx context.Context, t RunnableTB[T], name string,
 		t.Log(fmt.Sprintf("Step %s did not run - did you call test with a sub-filter?", step.desc))
 		t.FailNow()
 	}
-	return success
+	return success, asserter != nil && asserter.skipped
+}
+
+// skipStep reports a step which will not run. The step is skipped when the test
+// supports skipping, otherwise the reason is logged.
+func (ss *Stepper[T]) skipStep(t RunnableTB[T], name string, reason string) {
+	t.Run(name, func(t T) {
+		if skipper, ok := any(t).(skipper); ok {
+			skipper.Skip(reason)
+			return
+		}
+		t.Log(reason)
+	})
+}
+
+// goexitTB wraps a test for callbacks run outside of the goroutine running the
+// test, where FailNow marks the test as failed and exits only the callback.
+type goexitTB struct {
+	RequiresTB
+}
+
+func (t goexitTB) FailNow() {
+	t.Fail()
+	runtime.Goexit()
+}
+
+func (t goexitTB) LevelLog(level LogLevel, args ...any) {
+	t.RequiresTB.Helper()
+	logAtLevel(t.RequiresTB, level, args...)
+}
+
+func (t goexitTB) RecordError(err error) {
+	recordError(t.RequiresTB, err)
+}
+
+// Skip ends the callback without marking the test as skipped, as the test
+// may not be skipped from another goroutine.
+func (t goexitTB) Skip(args ...any) {
+	t.RequiresTB.Helper()
+	t.Log(args...)
+	runtime.Goexit()
+}
+
+func (t goexitTB) SkipNow() {
+	runtime.Goexit()
+}
+
+// detachedTB wraps the parent test for a variation running in its own
+// goroutine. FailNow must only be called from the goroutine running the test,
+// so it instead marks the parent as failed and exits the variation.
+type detachedTB[T RequiresTB] struct {
+	RunnableTB[T]
+}
+
+func (t detachedTB[T]) FailNow() {
+	t.Fail()
+	runtime.Goexit()
+}
+
+func (t detachedTB[T]) LevelLog(level LogLevel, args ...any) {
+	t.RunnableTB.Helper()
+	logAtLevel(t.RunnableTB, level, args...)
+}
+
+func (t detachedTB[T]) RecordError(err error) {
+	recordError(t.RunnableTB, err)
+}
+
+// Skip ends the variation, without marking the parent as skipped.
+func (t detachedTB[T]) Skip(args ...any) {
+	t.RunnableTB.Helper()
+	t.Log(args...)
+	runtime.Goexit()
+}
+
+func (t detachedTB[T]) SkipNow() {
+	runtime.Goexit()
 }
 
 // TB is the subset of the testing.TB interface which the stepper's asserter
 // implements.
 type TB interface {
 	Context() context.Context
-	//Cleanup(func())
+	Cleanup(func())
 	Error(args ...any)
 	Errorf(format string, args ...any)
 	//Fail()
@@ -385,10 +756,10 @@ type TB interface {
 	Logf(format string, args ...any)
 	//Name() string
 	//Setenv(key, value string)
-	//Skip(args ...any)
-	//SkipNow()
-	//Skipf(format string, args ...any)
-	//Skipped() bool
+	Skip(args ...any)
+	SkipNow()
+	Skipf(format string, args ...any)
+	Skipped() bool
 	//TempDir() string
 }
 
@@ -413,6 +784,7 @@ type stepRun struct {
 	RequiresTB
 	context context.Context
 	failed  bool
+	skipped bool
 	cancel  func()
 	*assertion
 }
@@ -425,19 +797,79 @@ func (t *stepRun) Failed() bool {
 	return t.failed
 }
 
+// skipper is implemented by tests which can be marked as skipped, e.g.
+// testing.T. SkipNow and Skip must not return.
+type skipper interface {
+	Skip(args ...any)
+	SkipNow()
+}
+
+// Skip logs the args and skips the step. When called from the Variation,
+// the remaining steps of the variation are also skipped.
+func (t *stepRun) Skip(args ...any) {
+	t.Helper()
+	t.skipped = true
+	if skipper, ok := t.RequiresTB.(skipper); ok {
+		skipper.Skip(args...)
+	}
+	t.log(LogLevelDefault, args...)
+	runtime.Goexit()
+}
+
+func (t *stepRun) Skipf(format string, args ...any) {
+	t.Helper()
+	t.Skip(fmt.Sprintf(format, args...))
+}
+
+func (t *stepRun) SkipNow() {
+	t.Helper()
+	t.skipped = true
+	if skipper, ok := t.RequiresTB.(skipper); ok {
+		skipper.SkipNow()
+	}
+	runtime.Goexit()
+}
+
+func (t *stepRun) Skipped() bool {
+	return t.skipped
+}
+
+// Cleanup registers a function to run at the end of the variation, along with
+// the Teardown hooks of the stepper.
+func (t *stepRun) Cleanup(fn func()) {
+	state, ok := t.context.Value(runStateKey{}).(*runState)
+	if !ok {
+		t.Fatal("Cleanup called outside of a running stepper")
+		return
+	}
+	state.addCleanup(func(context.Context, Asserter) error {
+		fn()
+		return nil
+	})
+}
+
 func (t *stepRun) log(level LogLevel, args ...any) {
 	t.Helper()
-	if levelLogger, ok := t.RequiresTB.(levelLogger); ok {
+	logAtLevel(t.RequiresTB, level, args...)
+}
+
+func logAtLevel(t RequiresTB, level LogLevel, args ...any) {
+	t.Helper()
+	if levelLogger, ok := t.(levelLogger); ok {
 		levelLogger.LevelLog(level, args...)
 	} else {
 		if level == LogLevelDefault {
-			t.RequiresTB.Log(args...)
+			t.Log(args...)
 		} else {
-			t.RequiresTB.Log(fmt.Sprintf("%s: %s", level, fmt.Sprint(args...)))
+			t.Log(fmt.Sprintf("%s: %s", level, fmt.Sprint(args...)))
 		}
 	}
 }
 
+func (t *stepRun) recordError(err error) {
+	recordError(t.RequiresTB, err)
+}
+
 func (t *stepRun) Log(args ...any) {
 	t.Helper()
 	t.log(LogLevelDefault, args...)
@@ -460,9 +892,27 @@ type levelLogger interface {
 	LevelLog(level LogLevel, args ...any)
 }
 
+// errorRecorder is implemented by tests which collect the errors causing
+// failures, e.g. to decide if the failure is worth retrying.
+type errorRecorder interface {
+	RecordError(err error)
+}
+
+func recordError(t RequiresTB, err error) {
+	if recorder, ok := t.(errorRecorder); ok {
+		recorder.RecordError(err)
+	}
+}
+
 func (t *stepRun) Fatal(args ...any) {
 	t.Helper()
-	t.log(LogLevelFatal, fmt.Sprint(args...))
+	if len(args) == 1 {
+		// A single value, e.g. a *be.Outcome, is passed on as is for the
+		// logger to render.
+		t.log(LogLevelFatal, args[0])
+	} else {
+		t.log(LogLevelFatal, fmt.Sprint(args...))
+	}
 	t.FailNow()
 }
 
@@ -497,6 +947,7 @@ func (t *stepRun) anon() *assertion {
 		helper:          t.Helper,
 		fatal:           t.Fatal,
 		error:           t.Error,
+		record:          t.recordError,
 		assertionParent: t,
 	}
 }
@@ -2,7 +2,14 @@ package flowtest
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // ensure that testing.T implements what the Asserter needs
@@ -29,3 +36,411 @@ func TestStepperStops(t *testing.T) {
 	})
 
 }
+
+func TestParallelVariations(t *testing.T) {
+	ss := NewStepper[*testing.T](t.Name())
+	defer ss.RunSteps(t)
+
+	ss.ParallelVariations()
+
+	barrier := sync.WaitGroup{}
+	barrier.Add(2)
+
+	ss.Variation("a", func(ctx context.Context, a Asserter) {})
+	ss.Variation("b", func(ctx context.Context, a Asserter) {})
+
+	ss.Step("wait for both", func(ctx context.Context, a Asserter) {
+		if ss.currentAsserter(ctx) != a.(*stepRun) {
+			a.Fatal("context did not route to the running step")
+		}
+
+		barrier.Done()
+		done := make(chan struct{})
+		go func() {
+			barrier.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			a.Fatal("variations did not run concurrently")
+		}
+	})
+}
+
+// fakeTB is a minimal RunnableTB which records what ran, allowing the stepper
+// to be tested with failing steps.
+type fakeTB struct {
+	name    string
+	lock    sync.Mutex
+	failed  bool
+	skipped bool
+	logs    []string
+	runs    []*fakeTB
+
+	// locations has the file:line of each log, skipping helpers as
+	// testing.T does.
+	locations []string
+}
+
+// fakeHelpers has the functions marked by fakeTB.Helper.
+var fakeHelpers sync.Map
+
+func (t *fakeTB) Context() context.Context {
+	return context.Background()
+}
+
+func (t *fakeTB) Helper() {
+	pc, _, _, _ := runtime.Caller(1)
+	fakeHelpers.Store(runtime.FuncForPC(pc).Name(), true)
+}
+
+func (t *fakeTB) Log(args ...any) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.logs = append(t.logs, fmt.Sprint(args...))
+	t.locations = append(t.locations, callerLocation())
+}
+
+// callerLocation returns the file:line of the first caller of fakeTB.Log
+// which is not a helper.
+func callerLocation() string {
+	pcs := make([]uintptr, 50)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if _, ok := fakeHelpers.Load(frame.Function); !ok || !more {
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+	}
+}
+
+// nextLine returns the file:line after its caller.
+func nextLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line+1)
+}
+
+// allLocations returns the log locations of the test and all of its runs.
+func (t *fakeTB) allLocations() []string {
+	locations := slices.Clone(t.locations)
+	for _, run := range t.runs {
+		locations = append(locations, run.allLocations()...)
+	}
+	return locations
+}
+
+func (t *fakeTB) Fail() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.failed = true
+}
+
+func (t *fakeTB) FailNow() {
+	t.Fail()
+	runtime.Goexit()
+}
+
+func (t *fakeTB) Skip(args ...any) {
+	t.Log(args...)
+	t.SkipNow()
+}
+
+func (t *fakeTB) SkipNow() {
+	t.lock.Lock()
+	t.skipped = true
+	t.lock.Unlock()
+	runtime.Goexit()
+}
+
+func (t *fakeTB) Run(name string, f func(*fakeTB)) bool {
+	child := &fakeTB{name: name}
+	t.lock.Lock()
+	t.runs = append(t.runs, child)
+	t.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(child)
+	}()
+	<-done
+
+	if child.failed {
+		t.Fail()
+	}
+	return !child.failed
+}
+
+func (t *fakeTB) child(name string) *fakeTB {
+	for _, run := range t.runs {
+		if run.name == name {
+			return run
+		}
+	}
+	return nil
+}
+
+func TestFailurePolicy(t *testing.T) {
+
+	build := func(policy FailurePolicy) *fakeTB {
+		ss := NewStepper[*fakeTB](t.Name())
+		ss.OnFailure(policy)
+
+		ss.Variation("a", func(ctx context.Context, a Asserter) {})
+		ss.Variation("b", func(ctx context.Context, a Asserter) {})
+		ss.Step("fails in a", func(ctx context.Context, a Asserter) {
+			if ss.currentAsserter(ctx) != a.(*stepRun) {
+				a.Fatal("context did not route to the running step")
+			}
+			if a.(*stepRun).RequiresTB.(*fakeTB).name == "vary 0 0 fails in a" {
+				a.Fatal("failing")
+			}
+		})
+		ss.Step("after", func(ctx context.Context, a Asserter) {})
+
+		tb := &fakeTB{}
+		ss.RunStepsWithContext(context.Background(), tb)
+		if !tb.failed {
+			t.Fatal("expected the run to fail")
+		}
+		return tb
+	}
+
+	t.Run("fail fast", func(t *testing.T) {
+		tb := build(FailFast)
+		if tb.child("vary 1 b") != nil {
+			t.Error("variation b should not run")
+		}
+	})
+
+	t.Run("continue variations", func(t *testing.T) {
+		tb := build(ContinueVariations)
+		if tb.child("vary 0 1 after") != nil {
+			t.Error("step after the failure should not run")
+		}
+		if tb.child("vary 1 1 after") == nil {
+			t.Error("variation b should run to completion")
+		}
+	})
+
+	t.Run("report skipped", func(t *testing.T) {
+		tb := build(ReportSkipped)
+		skipped := tb.child("vary 0 1 after")
+		if skipped == nil || !skipped.skipped {
+			t.Fatal("step after the failure should be reported as skipped")
+		}
+		if want := "skipped because step vary 0 0 fails in a failed"; len(skipped.logs) != 1 || skipped.logs[0] != want {
+			t.Errorf("got skip logs %v, want %q", skipped.logs, want)
+		}
+		if tb.child("vary 1 1 after") == nil {
+			t.Error("variation b should run to completion")
+		}
+	})
+
+	t.Run("parallel fail fast", func(t *testing.T) {
+		ss := NewStepper[*fakeTB](t.Name())
+		ss.ParallelVariations()
+
+		ss.Variation("a", func(ctx context.Context, a Asserter) {})
+		ss.Variation("b", func(ctx context.Context, a Asserter) {})
+		started := make(chan struct{})
+		canceled := make(chan bool, 1)
+		ss.Step("fails in a", func(ctx context.Context, a Asserter) {
+			if a.(*stepRun).RequiresTB.(*fakeTB).name == "vary 0 0 fails in a" {
+				<-started
+				a.Fatal("failing")
+			}
+			close(started)
+			select {
+			case <-ctx.Done():
+				canceled <- true
+			case <-time.After(time.Second):
+				canceled <- false
+			}
+		})
+		ss.Step("after", func(ctx context.Context, a Asserter) {})
+
+		tb := &fakeTB{}
+		ss.RunStepsWithContext(context.Background(), tb)
+		if !tb.failed {
+			t.Fatal("expected the run to fail")
+		}
+		if !<-canceled {
+			t.Error("variation b was not canceled")
+		}
+		if tb.child("vary 1 1 after") != nil {
+			t.Error("variation b should not run further steps")
+		}
+	})
+}
+
+func TestLogLocation(t *testing.T) {
+	ss := NewStepper[*fakeTB](t.Name())
+	ss.ParallelVariations()
+
+	var setupLine, variationLine, teardownLine, cleanupLine string
+	ss.Teardown(func(ctx context.Context, a Asserter) error {
+		teardownLine = nextLine()
+		a.Log("teardown")
+		return nil
+	})
+	ss.Setup(func(ctx context.Context, a Asserter) error {
+		setupLine = nextLine()
+		a.Log("setup")
+		return nil
+	})
+	ss.Variation("a", func(ctx context.Context, a Asserter) {
+		variationLine = nextLine()
+		a.Log("variation")
+	})
+	ss.Step("cleanup", func(ctx context.Context, a Asserter) {
+		a.Cleanup(func() {
+			cleanupLine = nextLine()
+			a.Log("cleanup")
+		})
+	})
+
+	tb := &fakeTB{}
+	ss.RunStepsWithContext(context.Background(), tb)
+
+	locations := tb.allLocations()
+	for _, want := range []string{setupLine, variationLine, teardownLine, cleanupLine} {
+		if !slices.Contains(locations, want) {
+			t.Errorf("no log at %s, got %v", want, locations)
+		}
+	}
+}
+
+func TestTeardown(t *testing.T) {
+	ss := NewStepper[*fakeTB](t.Name())
+
+	order := []string{}
+	ss.Teardown(func(ctx context.Context, a Asserter) error {
+		order = append(order, "teardown 1")
+		return nil
+	})
+	ss.Teardown(func(ctx context.Context, a Asserter) error {
+		order = append(order, "teardown 2")
+		a.Fatal("teardown fails")
+		return nil
+	})
+	ss.Step("cleanup then fail", func(ctx context.Context, a Asserter) {
+		a.Cleanup(func() {
+			order = append(order, "cleanup")
+		})
+		a.Fatal("step fails")
+	})
+	ss.Step("after", func(ctx context.Context, a Asserter) {
+		t.Error("step after failure should not run")
+	})
+
+	tb := &fakeTB{}
+	ss.RunStepsWithContext(context.Background(), tb)
+
+	want := []string{"cleanup", "teardown 2", "teardown 1"}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("got order %v, want %v", order, want)
+	}
+
+	teardown := tb.child("teardown")
+	if teardown == nil {
+		t.Fatal("teardown was not run as a step")
+	}
+	if !teardown.failed {
+		t.Error("teardown failure was not reported")
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	ss := NewStepper[*fakeTB](t.Name())
+	ss.SetTimeouts(Timeouts{
+		Step: 50 * time.Millisecond,
+		Hook: 50 * time.Millisecond,
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	ss.Teardown(func(ctx context.Context, a Asserter) error {
+		// Ignores the context, so must be abandoned.
+		<-release
+		return nil
+	})
+
+	hadDeadline := false
+	ss.Step("blocks", func(ctx context.Context, a Asserter) {
+		_, hadDeadline = ctx.Deadline()
+		<-ctx.Done()
+	})
+
+	tb := &fakeTB{}
+	ss.RunStepsWithContext(context.Background(), tb)
+
+	if !hadDeadline {
+		t.Error("step context had no deadline")
+	}
+
+	step := tb.runs[0]
+	if !step.failed {
+		t.Error("step did not fail")
+	}
+	if len(step.logs) == 0 || !strings.Contains(step.logs[0], "step "+step.name+" timed out after 50ms") {
+		t.Errorf("unexpected step logs %q", step.logs)
+	}
+
+	teardown := tb.child("teardown")
+	if teardown == nil || !teardown.failed {
+		t.Fatal("teardown timeout was not reported")
+	}
+	if !strings.Contains(strings.Join(teardown.logs, "\n"), "Teardown hook timed out") {
+		t.Errorf("unexpected teardown logs %q", teardown.logs)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	ss := NewStepper[*fakeTB](t.Name())
+
+	ran := []string{}
+	ss.Variation("not deployed", func(ctx context.Context, a Asserter) {
+		a.Skip("feature not deployed")
+	})
+	ss.Variation("deployed", func(ctx context.Context, a Asserter) {})
+	ss.Step("optional", func(ctx context.Context, a Asserter) {
+		a.Skipf("optional in %s", "dev")
+		t.Error("Skip should not return")
+	})
+	ss.Step("required", func(ctx context.Context, a Asserter) {
+		ran = append(ran, "required")
+	})
+
+	tb := &fakeTB{}
+	ss.RunStepsWithContext(context.Background(), tb)
+
+	if tb.failed {
+		t.Error("skipping should not fail the test")
+	}
+	if fmt.Sprint(ran) != "[required]" {
+		t.Errorf("required should run once, for the deployed variation, got %v", ran)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		skipped bool
+	}{
+		{"vary 0 not deployed", true},
+		{"vary 0 0 optional", true},
+		{"vary 0 1 required", true},
+		{"vary 1 deployed", false},
+		{"vary 1 0 optional", true},
+		{"vary 1 1 required", false},
+	} {
+		step := tb.child(tc.name)
+		if step == nil {
+			t.Errorf("step %s did not run", tc.name)
+			continue
+		}
+		if step.skipped != tc.skipped {
+			t.Errorf("step %s skipped %v, want %v", tc.name, step.skipped, tc.skipped)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package flowtest
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Timeouts limit how long parts of a Stepper run may take. When a timeout
+// fires, the test fails with a message naming what timed out, followed by the
+// stacks of all goroutines. Zero values are not limited.
+type Timeouts struct {
+	// Run limits the whole RunSteps call, including every variation.
+	Run time.Duration
+
+	// Step limits each step, including its pre and post hooks. The deadline
+	// is set on the context passed to the step.
+	Step time.Duration
+
+	// Hook limits the Setup hooks, each Teardown hook, and the time for
+	// Background hooks to return once the steps are complete.
+	Hook time.Duration
+}
+
+// SetTimeouts sets the timeouts for the run, steps and hooks.
+func (ss *Stepper[_]) SetTimeouts(timeouts Timeouts) {
+	ss.timeouts = timeouts
+}
+
+// goroutineStacks returns the stacks of all running goroutines.
+func goroutineStacks() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+func timeoutMessage(name string, timeout time.Duration) string {
+	return fmt.Sprintf("%s timed out after %s\n%s", name, timeout, goroutineStacks())
+}
+
+// startWatchdog fails the test and calls cancel if the returned stop function
+// is not called within the timeout. The test must not complete before stop is
+// called. A stop after the timeout has passed also fails the test, as the
+// caller may have returned on a context deadline of the same timeout before
+// the timer fired.
+func startWatchdog(t RequiresTB, timeout time.Duration, name string, cancel func()) func() {
+	if timeout <= 0 {
+		return func() {}
+	}
+
+	var lock sync.Mutex
+	done := false
+	start := time.Now()
+	fire := func() {
+		logAtLevel(t, LogLevelError, timeoutMessage(name, timeout))
+		t.Fail()
+		cancel()
+	}
+	timer := time.AfterFunc(timeout, func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if done {
+			return
+		}
+		done = true
+		fire()
+	})
+
+	return func() {
+		timer.Stop()
+		lock.Lock()
+		defer lock.Unlock()
+		if done {
+			return
+		}
+		done = true
+		if time.Since(start) >= timeout {
+			fire()
+		}
+	}
+}
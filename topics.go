@@ -0,0 +1,189 @@
+package flowtest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// PublishedMessage is a message received by a TopicHarness.
+type PublishedMessage struct {
+	// Topic is the full name of the topic service, e.g. "foo.v1.FooTopic".
+	Topic string
+
+	// Method is the name of the method within the topic service.
+	Method string
+
+	Message  proto.Message
+	Metadata metadata.MD
+}
+
+// TopicHarness records the messages published to topic services, i.e. gRPC
+// services with unary methods returning google.protobuf.Empty, registered on a
+// GRPCPair.Server in place of the real message broker.
+//
+// Messages are recorded until Reset. To assert on the messages of each step,
+// pass ResetHook to the PreStepHook of the stepper.
+type TopicHarness struct {
+	lock     sync.Mutex
+	messages []*PublishedMessage
+
+	// published is closed and replaced on each publish, to wake WaitFor.
+	published chan struct{}
+}
+
+func NewTopicHarness() *TopicHarness {
+	return &TopicHarness{
+		published: make(chan struct{}),
+	}
+}
+
+// Register serves the topic services on the server, recording every request.
+func (th *TopicHarness) Register(server *grpc.Server, topics ...protoreflect.ServiceDescriptor) {
+	for _, topic := range topics {
+		RegisterDynamicService(server, topic, th.handle)
+	}
+}
+
+func (th *TopicHarness) handle(ctx context.Context, method protoreflect.MethodDescriptor, req proto.Message) (proto.Message, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	th.lock.Lock()
+	defer th.lock.Unlock()
+	th.messages = append(th.messages, &PublishedMessage{
+		Topic:    string(method.Parent().FullName()),
+		Method:   string(method.Name()),
+		Message:  req,
+		Metadata: md,
+	})
+	close(th.published)
+	th.published = make(chan struct{})
+	return newMessage(method.Output()), nil
+}
+
+// Messages returns the messages published since the last Reset. When topics
+// are given, only messages to those topics are returned.
+func (th *TopicHarness) Messages(topics ...string) []*PublishedMessage {
+	th.lock.Lock()
+	defer th.lock.Unlock()
+	return th.filter(topics)
+}
+
+func (th *TopicHarness) filter(topics []string) []*PublishedMessage {
+	messages := make([]*PublishedMessage, 0, len(th.messages))
+	for _, msg := range th.messages {
+		if len(topics) == 0 || slices.Contains(topics, msg.Topic) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Reset forgets all published messages.
+func (th *TopicHarness) Reset() {
+	th.lock.Lock()
+	defer th.lock.Unlock()
+	th.messages = nil
+}
+
+// ResetHook resets the harness, for use as a PreStepHook.
+func (th *TopicHarness) ResetHook(ctx context.Context, a Asserter) error {
+	th.Reset()
+	return nil
+}
+
+// None asserts that no messages were published since the last Reset, or none
+// to the given topics.
+func (th *TopicHarness) None(a Assertion, topics ...string) {
+	a.Helper()
+	messages := th.Messages(topics...)
+	if len(messages) > 0 {
+		a.Fatalf("got %d published messages, want none:\n%s", len(messages), describeMessages(messages))
+	}
+}
+
+func describeMessages(messages []*PublishedMessage) string {
+	if len(messages) == 0 {
+		return "  (none)"
+	}
+	lines := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		lines = append(lines, fmt.Sprintf("  %s/%s: %s", msg.Topic, msg.Method, marshalLogMessage(msg.Message)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// matching returns the messages of type T for which match returns true. A nil
+// match matches every message of the type.
+func matching[T proto.Message](messages []*PublishedMessage, match func(T) bool) []T {
+	matched := []T{}
+	for _, msg := range messages {
+		typed, ok := msg.Message.(T)
+		if !ok {
+			continue
+		}
+		if match == nil || match(typed) {
+			matched = append(matched, typed)
+		}
+	}
+	return matched
+}
+
+func typeName[T proto.Message]() protoreflect.FullName {
+	var zero T
+	return zero.ProtoReflect().Descriptor().FullName()
+}
+
+// ExactlyOne asserts that exactly one message of type T for which match
+// returns true was published since the last Reset, and returns it. A nil match
+// matches every message of the type.
+func ExactlyOne[T proto.Message](a Assertion, th *TopicHarness, match func(T) bool) T {
+	a.Helper()
+	messages := th.Messages()
+	matched := matching(messages, match)
+	if len(matched) != 1 {
+		a.Fatalf("got %d matching %s messages, want exactly one, published:\n%s", len(matched), typeName[T](), describeMessages(messages))
+		var zero T
+		return zero
+	}
+	return matched[0]
+}
+
+// WaitFor waits until a message of type T for which match returns true has
+// been published since the last Reset, and returns the first such message. It
+// fails when the timeout passes or the context is done first. A nil match
+// matches every message of the type.
+func WaitFor[T proto.Message](ctx context.Context, a Assertion, th *TopicHarness, timeout time.Duration, match func(T) bool) T {
+	a.Helper()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		th.lock.Lock()
+		matched := matching(th.messages, match)
+		published := th.published
+		th.lock.Unlock()
+		if len(matched) > 0 {
+			return matched[0]
+		}
+
+		select {
+		case <-published:
+		case <-timer.C:
+			a.Fatalf("no matching %s message published after %s, published:\n%s", typeName[T](), timeout, describeMessages(th.Messages()))
+			var zero T
+			return zero
+		case <-ctx.Done():
+			a.Fatalf("%s waiting for a matching %s message, published:\n%s", ctx.Err(), typeName[T](), describeMessages(th.Messages()))
+			var zero T
+			return zero
+		}
+	}
+}
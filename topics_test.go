@@ -0,0 +1,145 @@
+package flowtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testService builds a service descriptor where each method takes the
+// input type and returns the output type, given as full names.
+func testService(t *testing.T, name string, input, output protoreflect.FullName, methods ...string) protoreflect.ServiceDescriptor {
+	t.Helper()
+	service := &descriptorpb.ServiceDescriptorProto{
+		Name: proto.String(name),
+	}
+	for _, method := range methods {
+		service.Method = append(service.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(method),
+			InputType:  proto.String("." + string(input)),
+			OutputType: proto.String("." + string(output)),
+		})
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String(strings.ToLower(name) + ".proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		Dependency: []string{
+			"google/protobuf/empty.proto",
+			"google/protobuf/wrappers.proto",
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{service},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file.Services().Get(0)
+}
+
+func TestTopicHarness(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	topic := testService(t, "OrderTopic",
+		"google.protobuf.StringValue", "google.protobuf.Empty",
+		"OrderCreated", "OrderShipped")
+
+	pair := NewGRPCPair(t)
+	topics := NewTopicHarness()
+	topics.Register(pair.Server, topic)
+	pair.ServeUntilDone(t, ctx)
+
+	publish := func(method string, id string) {
+		t.Helper()
+		if err := pair.Client.Invoke(ctx, "/test.OrderTopic/"+method, wrapperspb.String(id), &emptypb.Empty{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(fn func(a Assertion)) (bool, string) {
+		tw := &testWrap{}
+		fn(&assertion{
+			fatal:           tw.Fatal,
+			helper:          tw.Helper,
+			assertionParent: tw,
+		})
+		return tw.failed, tw.message
+	}
+
+	if failed, msg := run(func(a Assertion) { topics.None(a) }); failed {
+		t.Errorf("unexpected failure %s", msg)
+	}
+
+	publish("OrderCreated", "order-1")
+	publish("OrderShipped", "order-1")
+	publish("OrderCreated", "order-2")
+
+	if got := len(topics.Messages("test.OrderTopic")); got != 3 {
+		t.Errorf("got %d messages", got)
+	}
+	if got := len(topics.Messages("test.OtherTopic")); got != 0 {
+		t.Errorf("got %d messages to another topic", got)
+	}
+
+	failed, msg := run(func(a Assertion) {
+		msg := ExactlyOne(a, topics, func(msg *wrapperspb.StringValue) bool {
+			return msg.Value == "order-2"
+		})
+		if msg.GetValue() != "order-2" {
+			t.Errorf("got message %v", msg)
+		}
+	})
+	if failed {
+		t.Errorf("unexpected failure %s", msg)
+	}
+
+	failed, msg = run(func(a Assertion) {
+		ExactlyOne[*wrapperspb.StringValue](a, topics, func(msg *wrapperspb.StringValue) bool {
+			return msg.Value == "order-1"
+		})
+	})
+	if !failed || !strings.Contains(msg, "got 2 matching google.protobuf.StringValue messages") {
+		t.Errorf("unexpected failure %q", msg)
+	}
+
+	failed, msg = run(func(a Assertion) { topics.None(a) })
+	if !failed || !strings.Contains(msg, `test.OrderTopic/OrderShipped: "order-1"`) {
+		t.Errorf("unexpected failure %q", msg)
+	}
+
+	topics.Reset()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		publish("OrderCreated", "order-3")
+	}()
+	failed, msg = run(func(a Assertion) {
+		msg := WaitFor(ctx, a, topics, time.Second, func(msg *wrapperspb.StringValue) bool {
+			return msg.Value == "order-3"
+		})
+		if msg.GetValue() != "order-3" {
+			t.Errorf("got message %v", msg)
+		}
+	})
+	if failed {
+		t.Errorf("unexpected failure %s", msg)
+	}
+
+	failed, msg = run(func(a Assertion) {
+		WaitFor[*wrapperspb.StringValue](ctx, a, topics, 10*time.Millisecond, nil)
+		WaitFor(ctx, a, topics, 10*time.Millisecond, func(msg *wrapperspb.StringValue) bool {
+			return msg.Value == "order-4"
+		})
+	})
+	if !failed || !strings.Contains(msg, "no matching google.protobuf.StringValue message published after 10ms") {
+		t.Errorf("unexpected failure %q", msg)
+	}
+}
@@ -0,0 +1,158 @@
+package flowtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/bufbuild/protovalidate-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var defaultValidator = sync.OnceValues(func() (*protovalidate.Validator, error) {
+	return protovalidate.New()
+})
+
+// ProtoViolations returns the violations of the protovalidate rules of msg,
+// or an error when the rules can not be evaluated.
+func ProtoViolations(msg proto.Message) ([]*validate.Violation, error) {
+	validator, err := defaultValidator()
+	if err != nil {
+		return nil, err
+	}
+	err = validator.Validate(msg)
+	valErr := &protovalidate.ValidationError{}
+	if errors.As(err, &valErr) {
+		return valErr.Violations, nil
+	}
+	return nil, err
+}
+
+// FormatViolations renders one violation per line, with its field path and
+// constraint.
+func FormatViolations(violations []*validate.Violation) string {
+	lines := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		lines = append(lines, fmt.Sprintf("  %s: %s [%s]", violation.FieldPath, violation.Message, violation.ConstraintId))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *assertion) Valid(msg proto.Message) {
+	a.helper()
+	violations, err := ProtoViolations(msg)
+	if err != nil {
+		a.fail("validating %s: %s", msg.ProtoReflect().Descriptor().FullName(), err)
+		return
+	}
+	if len(violations) > 0 {
+		a.fail("%s is not valid:\n%s", msg.ProtoReflect().Descriptor().FullName(), FormatViolations(violations))
+	}
+}
+
+func (a *assertion) Invalid(msg proto.Message, fieldPaths ...string) {
+	a.helper()
+	violations, err := ProtoViolations(msg)
+	if err != nil {
+		a.fail("validating %s: %s", msg.ProtoReflect().Descriptor().FullName(), err)
+		return
+	}
+	if len(violations) == 0 {
+		a.fail("%s is valid, want violations", msg.ProtoReflect().Descriptor().FullName())
+		return
+	}
+	for _, path := range fieldPaths {
+		if !slices.ContainsFunc(violations, func(v *validate.Violation) bool {
+			return v.FieldPath == path
+		}) {
+			a.fail("%s has no violation for %q, got:\n%s", msg.ProtoReflect().Descriptor().FullName(), path, FormatViolations(violations))
+			return
+		}
+	}
+}
+
+// WithValidation validates every request and response of the client of a
+// GRPCPair against its protovalidate rules. Violations fail the step which
+// made the call. Outside of a stepper, calls with invalid requests or
+// responses return an error instead.
+func WithValidation() GRPCPairOption {
+	return func(c *grpcPairConfig) {
+		c.unaryClient = append(c.unaryClient, validateUnaryClientInterceptor)
+		c.streamClient = append(c.streamClient, validateStreamClientInterceptor)
+	}
+}
+
+// checkValid reports violations of msg to the step of ctx, returning an error
+// when there is no step to report to.
+func checkValid(ctx context.Context, method string, kind string, msg any) error {
+	protoMsg, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+	violations, err := ProtoViolations(protoMsg)
+	if err == nil && len(violations) == 0 {
+		return nil
+	}
+
+	var message string
+	if err != nil {
+		message = fmt.Sprintf("validating %s %s: %s", method, kind, err)
+	} else {
+		message = fmt.Sprintf("%s %s is not valid:\n%s", method, kind, FormatViolations(violations))
+	}
+
+	if asserter := asserterFromContext(ctx); asserter != nil {
+		asserter.Error(message)
+		return nil
+	}
+	return status.Error(codes.Internal, message)
+}
+
+func validateUnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := checkValid(ctx, method, "request", req); err != nil {
+		return err
+	}
+	if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+		return err
+	}
+	return checkValid(ctx, method, "response", reply)
+}
+
+func validateStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &validatingClientStream{
+		ClientStream: stream,
+		ctx:          ctx,
+		method:       method,
+	}, nil
+}
+
+type validatingClientStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	method string
+}
+
+func (s *validatingClientStream) SendMsg(m any) error {
+	if err := checkValid(s.ctx, s.method, "request", m); err != nil {
+		return err
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *validatingClientStream) RecvMsg(m any) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkValid(s.ctx, s.method, "response", m)
+}
@@ -0,0 +1,130 @@
+package flowtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// validatedItem builds a message with a single string field, sku, which must
+// not be empty.
+func validatedItem(t *testing.T, sku string) proto.Message {
+	t.Helper()
+	fieldOptions := &descriptorpb.FieldOptions{}
+	proto.SetExtension(fieldOptions, validate.E_Field, &validate.FieldConstraints{
+		Type: &validate.FieldConstraints_String_{
+			String_: &validate.StringRules{MinLen: proto.Uint64(1)},
+		},
+	})
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("validated.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"buf/validate/validate.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Item"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:    proto.String("sku"),
+				Number:  proto.Int32(1),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Options: fieldOptions,
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := file.Messages().ByName("Item")
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("sku"), protoreflect.ValueOfString(sku))
+	return msg
+}
+
+func TestValid(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		assert  func(a Assertion)
+		failure string
+	}{{
+		name:   "valid",
+		assert: func(a Assertion) { a.Valid(validatedItem(t, "sku-1")) },
+	}, {
+		name:    "not valid",
+		assert:  func(a Assertion) { a.Valid(validatedItem(t, "")) },
+		failure: "test.Item is not valid:\n  sku: value length must be at least 1 characters [string.min_len]",
+	}, {
+		name:   "invalid",
+		assert: func(a Assertion) { a.Invalid(validatedItem(t, ""), "sku") },
+	}, {
+		name:    "invalid on another field",
+		assert:  func(a Assertion) { a.Invalid(validatedItem(t, ""), "name") },
+		failure: `test.Item has no violation for "name"`,
+	}, {
+		name:    "not invalid",
+		assert:  func(a Assertion) { a.Invalid(validatedItem(t, "sku-1")) },
+		failure: "test.Item is valid, want violations",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := &testWrap{}
+			a := &assertion{
+				fatal:  tw.Fatal,
+				helper: tw.Helper,
+			}
+			tc.assert(a)
+			if tc.failure == "" {
+				if tw.failed {
+					t.Errorf("unexpected failure: %s", tw.message)
+				}
+				return
+			}
+			if !strings.Contains(tw.message, tc.failure) {
+				t.Errorf("got failure %q, want %q", tw.message, tc.failure)
+			}
+		})
+	}
+}
+
+func TestValidationInterceptor(t *testing.T) {
+	invalid := validatedItem(t, "")
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+
+	t.Run("without a step", func(t *testing.T) {
+		err := validateUnaryClientInterceptor(context.Background(), "/test.Service/Get", invalid, validatedItem(t, "sku-1"), nil, invoker)
+		if status.Code(err) != codes.Internal || !strings.Contains(err.Error(), "/test.Service/Get request is not valid") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("within a step", func(t *testing.T) {
+		ss := NewStepper[*fakeTB](t.Name())
+		ss.Step("call", func(ctx context.Context, a Asserter) {
+			err := validateUnaryClientInterceptor(ctx, "/test.Service/Get", validatedItem(t, "sku-1"), invalid, nil, invoker)
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+
+		tb := &fakeTB{}
+		ss.RunStepsWithContext(context.Background(), tb)
+		step := tb.runs[0]
+		if !step.failed {
+			t.Fatal("invalid response did not fail the step")
+		}
+		if !strings.Contains(strings.Join(step.logs, "\n"), "/test.Service/Get response is not valid") {
+			t.Errorf("unexpected logs %q", step.logs)
+		}
+	})
+}